@@ -0,0 +1,439 @@
+package bzip2
+
+import (
+	"cmp"
+	"slices"
+)
+
+const (
+	runA = 0
+	runB = 1
+
+	// groupSize is the number of symbols coded with each selected table.
+	groupSize = 50
+
+	// maxCodeLen is the longest Huffman code written, as in the reference
+	// implementation. Decoders accept codes of up to 20 bits.
+	maxCodeLen = 17
+
+	// refinements is the number of times the tables are rebuilt from the
+	// symbols they were selected for.
+	refinements = 4
+)
+
+// writeBlock writes a block of run length encoded data and its CRC.
+func writeBlock(w *bitWriter, block []byte, crc uint32) {
+	w.write(48, blockMagic)
+	w.write(32, uint64(crc))
+	w.write(1, 0) // Not randomised.
+
+	last, origPtr := transform(block)
+	w.write(24, uint64(origPtr))
+
+	var inUse [256]bool
+	for _, c := range block {
+		inUse[c] = true
+	}
+
+	var ranges uint64
+	for i := range 16 {
+		if slices.Contains(inUse[i*16:i*16+16], true) {
+			ranges |= 0x8000 >> i
+		}
+	}
+	w.write(16, ranges)
+
+	for i := range 16 {
+		if ranges&(0x8000>>i) == 0 {
+			continue
+		}
+
+		var used uint64
+		for j := range 16 {
+			if inUse[i*16+j] {
+				used |= 0x8000 >> j
+			}
+		}
+		w.write(16, used)
+	}
+
+	symbols, alphaSize := moveToFront(last, &inUse)
+	writeSymbols(w, symbols, alphaSize)
+}
+
+// transform applies the Burrows-Wheeler transform to block, returning the
+// last column of its sorted rotations and the row of the block itself.
+func transform(block []byte) ([]byte, int) {
+	n := len(block)
+	rotations := sortRotations(block)
+
+	last := make([]byte, n)
+	origPtr := 0
+	for i, p := range rotations {
+		if p == 0 {
+			origPtr = i
+		}
+		last[i] = block[(int(p)+n-1)%n]
+	}
+
+	return last, origPtr
+}
+
+// sortRotations sorts the rotations of block by prefix doubling, ranking
+// rotations by their first 2^k bytes in each round with a counting sort.
+// Rotations that are equal are left in any order, which makes no
+// difference to the transform.
+func sortRotations(block []byte) []int32 {
+	n := len(block)
+	order := make([]int32, n)
+	rank := make([]int32, n)
+	next := make([]int32, n)
+	count := make([]int32, max(n, 256))
+
+	for _, c := range block {
+		count[c]++
+	}
+	for i := 1; i < 256; i++ {
+		count[i] += count[i-1]
+	}
+	for i := n - 1; i >= 0; i-- {
+		count[block[i]]--
+		order[count[block[i]]] = int32(i)
+	}
+
+	classes := int32(1)
+	for i := 1; i < n; i++ {
+		if block[order[i]] != block[order[i-1]] {
+			classes++
+		}
+		rank[order[i]] = classes - 1
+	}
+
+	for h := 1; h < n && int(classes) < n; h <<= 1 {
+		// Sorting by the second half of each rotation is just a shift of
+		// the current order, leaving a stable sort by the first half.
+		for i, p := range order {
+			next[i] = (p - int32(h) + int32(n)) % int32(n)
+		}
+
+		clear(count[:classes])
+		for _, p := range next {
+			count[rank[p]]++
+		}
+		for i := int32(1); i < classes; i++ {
+			count[i] += count[i-1]
+		}
+		for i := n - 1; i >= 0; i-- {
+			p := next[i]
+			count[rank[p]]--
+			order[count[rank[p]]] = p
+		}
+
+		next[order[0]] = 0
+		classes = 1
+		for i := 1; i < n; i++ {
+			cur, prev := order[i], order[i-1]
+			if rank[cur] != rank[prev] || rank[(int(cur)+h)%n] != rank[(int(prev)+h)%n] {
+				classes++
+			}
+			next[cur] = classes - 1
+		}
+		rank, next = next, rank
+	}
+
+	return order
+}
+
+// moveToFront applies the move to front transform to last, encoding runs
+// of zeroes with RUNA and RUNB and ending with the end of block symbol. It
+// returns the symbols and the size of the alphabet they are drawn from.
+func moveToFront(last []byte, inUse *[256]bool) ([]uint16, int) {
+	var seq [256]byte
+	var order [256]byte
+	used := 0
+	for c, ok := range inUse {
+		if ok {
+			seq[c] = byte(used)
+			order[used] = byte(used)
+			used++
+		}
+	}
+
+	symbols := make([]uint16, 0, len(last)+1)
+	zeroes := 0
+	for _, c := range last {
+		s := seq[c]
+		if order[0] == s {
+			zeroes++
+			continue
+		}
+
+		if zeroes > 0 {
+			symbols = appendRun(symbols, zeroes)
+			zeroes = 0
+		}
+
+		j := 1
+		prev := order[0]
+		for order[j] != s {
+			order[j], prev = prev, order[j]
+			j++
+		}
+		order[j] = prev
+		order[0] = s
+
+		symbols = append(symbols, uint16(j+1))
+	}
+
+	if zeroes > 0 {
+		symbols = appendRun(symbols, zeroes)
+	}
+
+	eob := used + 1
+	return append(symbols, uint16(eob)), eob + 1
+}
+
+// appendRun appends a run of n zeroes, written in bijective base 2 with
+// RUNA as 1 and RUNB as 2, least significant digit first.
+func appendRun(symbols []uint16, n int) []uint16 {
+	n--
+	for {
+		if n&1 != 0 {
+			symbols = append(symbols, runB)
+		} else {
+			symbols = append(symbols, runA)
+		}
+
+		if n < 2 {
+			return symbols
+		}
+		n = (n - 2) / 2
+	}
+}
+
+// writeSymbols writes the Huffman tables, their selectors and the symbols
+// of a block.
+func writeSymbols(w *bitWriter, symbols []uint16, alphaSize int) {
+	var groups int
+	switch n := len(symbols); {
+	case n < 200:
+		groups = 2
+	case n < 600:
+		groups = 3
+	case n < 1200:
+		groups = 4
+	case n < 2400:
+		groups = 5
+	default:
+		groups = 6
+	}
+
+	lengths := initialLengths(symbols, alphaSize, groups)
+	selectors := make([]uint8, 0, (len(symbols)+groupSize-1)/groupSize)
+
+	freqs := make([][]int32, groups)
+	for t := range freqs {
+		freqs[t] = make([]int32, alphaSize)
+	}
+
+	for range refinements {
+		selectors = selectors[:0]
+		for t := range freqs {
+			clear(freqs[t])
+		}
+
+		for start := 0; start < len(symbols); start += groupSize {
+			group := symbols[start:min(start+groupSize, len(symbols))]
+
+			best, bestCost := 0, -1
+			for t := range lengths {
+				cost := 0
+				for _, s := range group {
+					cost += int(lengths[t][s])
+				}
+				if bestCost < 0 || cost < bestCost {
+					best, bestCost = t, cost
+				}
+			}
+
+			selectors = append(selectors, uint8(best))
+			for _, s := range group {
+				freqs[best][s]++
+			}
+		}
+
+		for t := range lengths {
+			lengths[t] = codeLengths(freqs[t], maxCodeLen)
+		}
+	}
+
+	w.write(3, uint64(groups))
+	w.write(15, uint64(len(selectors)))
+
+	mtf := [6]uint8{0, 1, 2, 3, 4, 5}
+	for _, s := range selectors {
+		j := 0
+		for mtf[j] != s {
+			j++
+		}
+		copy(mtf[1:j+1], mtf[:j])
+		mtf[0] = s
+
+		for range j {
+			w.write(1, 1)
+		}
+		w.write(1, 0)
+	}
+
+	codes := make([][]uint32, groups)
+	for t, lens := range lengths {
+		codes[t] = assignCodes(lens)
+
+		cur := lens[0]
+		w.write(5, uint64(cur))
+		for _, l := range lens {
+			for ; cur < l; cur++ {
+				w.write(2, 2)
+			}
+			for ; cur > l; cur-- {
+				w.write(2, 3)
+			}
+			w.write(1, 0)
+		}
+	}
+
+	for i, s := range symbols {
+		t := selectors[i/groupSize]
+		w.write(uint(lengths[t][s]), uint64(codes[t][s]))
+	}
+}
+
+// initialLengths returns the tables the selectors are first chosen with,
+// each favouring a range of symbols that together make up an even share of
+// the block, as in the reference implementation.
+func initialLengths(symbols []uint16, alphaSize, groups int) [][]uint8 {
+	freq := make([]int, alphaSize)
+	for _, s := range symbols {
+		freq[s]++
+	}
+
+	lengths := make([][]uint8, groups)
+	remaining := len(symbols)
+	start := 0
+	for part := groups; part > 0; part-- {
+		target := remaining / part
+		end := start - 1
+		acc := 0
+		for acc < target && end < alphaSize-1 {
+			end++
+			acc += freq[end]
+		}
+
+		if end > start && part != groups && part != 1 && (groups-part)%2 == 1 {
+			acc -= freq[end]
+			end--
+		}
+
+		lens := make([]uint8, alphaSize)
+		for s := range lens {
+			if s < start || s > end {
+				lens[s] = 15
+			}
+		}
+		lengths[part-1] = lens
+
+		start = end + 1
+		remaining -= acc
+	}
+
+	return lengths
+}
+
+// codeLengths returns Huffman code lengths for symbols with the given
+// frequencies, no longer than maxLen. Every symbol is given a code, and the
+// frequencies are flattened until the longest code fits.
+func codeLengths(freqs []int32, maxLen int) []uint8 {
+	weights := make([]int64, len(freqs))
+	for i, f := range freqs {
+		weights[i] = max(int64(f), 1)
+	}
+
+	lens := make([]uint8, len(freqs))
+	for huffmanLengths(weights, lens) > maxLen {
+		for i := range weights {
+			weights[i] = 1 + weights[i]/2
+		}
+	}
+
+	return lens
+}
+
+// huffmanLengths stores the depth of each symbol in a Huffman tree built
+// for weights in lens, returning the greatest depth. There must be at
+// least 2 symbols.
+func huffmanLengths(weights []int64, lens []uint8) int {
+	n := len(weights)
+
+	leaves := make([]int, n)
+	for i := range leaves {
+		leaves[i] = i
+	}
+	slices.SortStableFunc(leaves, func(a, b int) int {
+		return cmp.Compare(weights[a], weights[b])
+	})
+
+	// Internal nodes are created in order of weight, so the two lightest
+	// nodes are always at the front of either the leaves or the nodes.
+	weight := make([]int64, 2*n-1)
+	parent := make([]int, 2*n-1)
+	copy(weight, weights)
+
+	leaf, node, next := 0, n, n
+	lightest := func() int {
+		if leaf < n && (node == next || weight[leaves[leaf]] <= weight[node]) {
+			leaf++
+			return leaves[leaf-1]
+		}
+		node++
+		return node - 1
+	}
+
+	for ; next < 2*n-1; next++ {
+		a, b := lightest(), lightest()
+		weight[next] = weight[a] + weight[b]
+		parent[a], parent[b] = next, next
+	}
+
+	// Every parent comes after its children, so depths can be filled in
+	// from the root down.
+	depth := make([]int, 2*n-1)
+	for i := 2*n - 3; i >= 0; i-- {
+		depth[i] = depth[parent[i]] + 1
+	}
+
+	longest := 0
+	for i := range lens {
+		lens[i] = uint8(depth[i])
+		longest = max(longest, depth[i])
+	}
+	return longest
+}
+
+// assignCodes returns the canonical Huffman codes for lens, assigned in
+// order of length and then symbol.
+func assignCodes(lens []uint8) []uint32 {
+	codes := make([]uint32, len(lens))
+
+	var code uint32
+	for l := uint8(1); l <= maxCodeLen; l++ {
+		for s, sl := range lens {
+			if sl == l {
+				codes[s] = code
+				code++
+			}
+		}
+		code <<= 1
+	}
+
+	return codes
+}
@@ -0,0 +1,145 @@
+// Package bzip2 implements a bzip2 compressor for the streams stored in
+// JS5 containers and .jag archives.
+//
+// The client strips the stream header from bzip2 data, as every stream it
+// reads uses 100k blocks. Compress writes streams in that headerless form
+// and NewReader reads them back.
+package bzip2
+
+import (
+	stdbzip2 "compress/bzip2"
+	"io"
+	"strings"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+// Header is the stream header the client strips, declaring 100k blocks.
+const Header = "BZh1"
+
+const (
+	blockMagic = 0x314159265359
+	endMagic   = 0x177245385090
+
+	// maxBlockLen is the most run length encoded bytes in a 100k block, with
+	// the same slack left at the end as the reference implementation.
+	maxBlockLen = 100000 - 19
+)
+
+// Compress compresses data and writes it to b as a headerless bzip2 stream.
+func Compress(b *jagbuf.Buffer, data []byte) {
+	w := &bitWriter{b: b}
+
+	var combinedCRC uint32
+	block := make([]byte, 0, maxBlockLen)
+	blockCRC := newCRC()
+
+	flush := func() {
+		crc := blockCRC.sum()
+		combinedCRC = (combinedCRC<<1 | combinedCRC>>31) ^ crc
+		writeBlock(w, block, crc)
+
+		block = block[:0]
+		blockCRC = newCRC()
+	}
+
+	// Runs of 4 to 255 bytes are encoded as 4 bytes followed by the number
+	// of repeats left, before the block is transformed.
+	for i := 0; i < len(data); {
+		c := data[i]
+		run := 1
+		for i+run < len(data) && data[i+run] == c && run < 255 {
+			run++
+		}
+
+		n := min(run, 4)
+		if run >= 4 {
+			n++
+		}
+		if len(block)+n > maxBlockLen {
+			flush()
+		}
+
+		for range min(run, 4) {
+			block = append(block, c)
+		}
+		if run >= 4 {
+			block = append(block, byte(run-4))
+		}
+
+		for range run {
+			blockCRC.update(c)
+		}
+		i += run
+	}
+
+	if len(block) > 0 {
+		flush()
+	}
+
+	w.write(48, endMagic)
+	w.write(32, uint64(combinedCRC))
+	w.flush()
+}
+
+// NewReader returns a reader that decompresses the headerless bzip2 stream
+// read from r.
+func NewReader(r io.Reader) io.Reader {
+	return stdbzip2.NewReader(io.MultiReader(strings.NewReader(Header), r))
+}
+
+// bitWriter writes bits most significant first, the order used by bzip2.
+type bitWriter struct {
+	b    *jagbuf.Buffer
+	bits uint64
+	n    uint
+}
+
+// write writes the low n bits of v, where n is at most 48.
+func (w *bitWriter) write(n uint, v uint64) {
+	w.bits = w.bits<<n | v
+	w.n += n
+
+	for w.n >= 8 {
+		w.n -= 8
+		w.b.WriteUint8(uint8(w.bits >> w.n))
+	}
+}
+
+// flush writes any bits left, padding the last byte with zeroes.
+func (w *bitWriter) flush() {
+	if w.n > 0 {
+		w.b.WriteUint8(uint8(w.bits << (8 - w.n)))
+		w.n = 0
+	}
+}
+
+// crc is the big endian CRC-32 bzip2 uses to check blocks.
+type crc uint32
+
+var crcTable = func() (table [256]uint32) {
+	for i := range table {
+		c := uint32(i) << 24
+		for range 8 {
+			if c&0x80000000 != 0 {
+				c = c<<1 ^ 0x04C11DB7
+			} else {
+				c <<= 1
+			}
+		}
+		table[i] = c
+	}
+	return table
+}()
+
+func newCRC() crc {
+	return 0xFFFFFFFF
+}
+
+func (c *crc) update(b byte) {
+	*c = crc(uint32(*c)<<8 ^ crcTable[byte(*c>>24)^b])
+}
+
+func (c crc) sum() uint32 {
+	return ^uint32(c)
+}
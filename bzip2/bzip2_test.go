@@ -0,0 +1,102 @@
+package bzip2
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"os/exec"
+	"testing"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+func roundTrip(t *testing.T, name string, data []byte) {
+	t.Helper()
+
+	buffer := jagbuf.NewBuffer()
+	Compress(buffer, data)
+
+	if bytes.HasPrefix(buffer.Bytes(), []byte(Header)) {
+		t.Fatalf("Compress fail: %s: expected no header", name)
+	}
+
+	decompressed, err := io.ReadAll(NewReader(buffer))
+	if err != nil {
+		t.Fatalf("Compress fail: %s: %v", name, err)
+	}
+
+	if !bytes.Equal(decompressed, data) {
+		t.Errorf("Compress fail: %s: expected %d bytes back, got %d", name, len(data), len(decompressed))
+	}
+}
+
+func TestCompress_RoundTrip(t *testing.T) {
+	random := make([]byte, 300000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	text := bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 5000)
+
+	runs := make([]byte, 0, 250000)
+	for i := range 1000 {
+		runs = append(runs, bytes.Repeat([]byte{byte(i)}, i%300)...)
+	}
+
+	all := make([]byte, 256)
+	for i := range all {
+		all[i] = byte(i)
+	}
+
+	tests := map[string][]byte{
+		"empty":        {},
+		"single byte":  {'a'},
+		"two symbols":  []byte("abababababababab"),
+		"run of 4":     []byte("aaaa"),
+		"run of 255":   bytes.Repeat([]byte{'x'}, 255),
+		"run of 256":   bytes.Repeat([]byte{'x'}, 256),
+		"long run":     bytes.Repeat([]byte{0}, 1000000),
+		"all bytes":    all,
+		"random":       random,
+		"text":         text,
+		"varied runs":  runs,
+		"block border": bytes.Repeat([]byte("ab"), maxBlockLen),
+	}
+
+	for name, data := range tests {
+		roundTrip(t, name, data)
+	}
+}
+
+func TestCompress_Bzip2Tool(t *testing.T) {
+	path, err := exec.LookPath("bzip2")
+	if err != nil {
+		t.Skip("bzip2 not installed")
+	}
+
+	data := bytes.Repeat([]byte("jagex cache data "), 10000)
+
+	buffer := jagbuf.NewBuffer()
+	buffer.Write([]byte(Header))
+	Compress(buffer, data)
+
+	cmd := exec.Command(path, "-d", "-c")
+	cmd.Stdin = buffer
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(out, data) {
+		t.Errorf("Compress fail: expected bzip2 to decompress %d bytes, got %d", len(data), len(out))
+	}
+}
+
+func TestCompress_Ratio(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible "), 10000)
+
+	buffer := jagbuf.NewBuffer()
+	Compress(buffer, data)
+
+	if buffer.ReadableBytes() > len(data)/50 {
+		t.Errorf("Compress fail: expected at most %d bytes, got %d", len(data)/50, buffer.ReadableBytes())
+	}
+}
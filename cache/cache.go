@@ -0,0 +1,317 @@
+// Package cache reads and writes the groups of a JS5 disk cache.
+//
+// A disk cache is made up of a single main_file_cache.dat2 file holding the
+// data of every group, and a main_file_cache.idxN file for each archive N.
+// The index holds a 6 byte entry for each group of the archive, giving the
+// length of the group and the first of the 520 byte sectors it is stored
+// in. Every sector starts with a header naming the group, archive and
+// position of the chunk it holds, and the sector holding the next chunk.
+package cache
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+const (
+	// DataFileName is the name of the file holding the data of every group.
+	DataFileName = "main_file_cache.dat2"
+
+	// ReferenceArchive is the archive holding the reference table of every
+	// other archive.
+	ReferenceArchive = 255
+)
+
+var (
+	// ErrNotFound is returned when a group is not in the cache.
+	ErrNotFound = errors.New("cache: group not found")
+
+	// ErrCorrupt is returned when the sectors of a group do not form a
+	// valid chain.
+	ErrCorrupt = errors.New("cache: corrupt sector chain")
+)
+
+// IndexFileName returns the name of the index file of an archive.
+func IndexFileName(archive uint8) string {
+	return fmt.Sprintf("main_file_cache.idx%d", archive)
+}
+
+// Store is a disk cache opened for reading and writing.
+type Store struct {
+	dir     string
+	data    *os.File
+	indexes [256]*os.File
+}
+
+// Open opens the disk cache in dir, which must have a data file. Index
+// files are created as archives are written.
+func Open(dir string) (*Store, error) {
+	return open(dir, os.O_RDWR)
+}
+
+// Create opens the disk cache in dir, creating the directory and an empty
+// data file if they do not exist.
+func Create(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return open(dir, os.O_RDWR|os.O_CREATE)
+}
+
+func open(dir string, flag int) (*Store, error) {
+	data, err := os.OpenFile(filepath.Join(dir, DataFileName), flag, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Store{dir: dir, data: data}
+	for archive := range s.indexes {
+		f, err := os.OpenFile(filepath.Join(dir, IndexFileName(uint8(archive))), os.O_RDWR, 0)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			s.Close()
+			return nil, err
+		}
+		s.indexes[archive] = f
+	}
+
+	return s, nil
+}
+
+// Close closes the files of the cache.
+func (s *Store) Close() error {
+	errs := []error{s.data.Close()}
+	for _, f := range s.indexes {
+		if f != nil {
+			errs = append(errs, f.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Archives returns the archives with an index file, in order.
+func (s *Store) Archives() []uint8 {
+	var archives []uint8
+	for archive, f := range s.indexes {
+		if f != nil {
+			archives = append(archives, uint8(archive))
+		}
+	}
+	return archives
+}
+
+// Groups returns the groups of an archive that are in the cache, in order.
+func (s *Store) Groups(archive uint8) ([]int, error) {
+	f := s.indexes[archive]
+	if f == nil {
+		return nil, nil
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, stat.Size()/IndexEntryLen*IndexEntryLen)
+	if _, err := f.ReadAt(data, 0); err != nil {
+		return nil, err
+	}
+
+	var groups []int
+	b := jagbuf.Wrap(data)
+	for group := 0; b.ReadableBytes() >= IndexEntryLen; group++ {
+		if readIndexEntry(b).sector != 0 {
+			groups = append(groups, group)
+		}
+	}
+	return groups, nil
+}
+
+// Read returns the data of a group, failing with ErrNotFound if it is not in
+// the cache and an error wrapping ErrCorrupt if its sectors are invalid.
+func (s *Store) Read(archive uint8, group int) ([]byte, error) {
+	e, err := s.entry(archive, group)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]byte, 0, e.size)
+	err = s.walk(archive, group, e, func(_ uint32, chunk []byte) {
+		data = append(data, chunk...)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Validate follows the sectors of a group without returning its data,
+// failing like Read if they are invalid.
+func (s *Store) Validate(archive uint8, group int) error {
+	e, err := s.entry(archive, group)
+	if err != nil {
+		return err
+	}
+	return s.walk(archive, group, e, func(uint32, []byte) {})
+}
+
+// Write stores the data of a group, overwriting the sectors it already has
+// where they are valid and appending new sectors to the data file after
+// them. The data file never shrinks: when a group is written shorter than
+// before, the sectors at the end of its old chain are orphaned rather than
+// reclaimed.
+func (s *Store) Write(archive uint8, group int, data []byte) error {
+	if len(data) > MaxGroupLen {
+		return fmt.Errorf("cache: group of %d bytes too long", len(data))
+	}
+
+	var reuse []uint32
+	e, err := s.entry(archive, group)
+	switch {
+	case err == nil:
+		// Only the sectors up to the first invalid one are overwritten.
+		err = s.walk(archive, group, e, func(sector uint32, _ []byte) {
+			reuse = append(reuse, sector)
+		})
+		if err != nil && !errors.Is(err, ErrCorrupt) {
+			return err
+		}
+	case !errors.Is(err, ErrNotFound):
+		return err
+	}
+
+	index, err := s.index(archive)
+	if err != nil {
+		return err
+	}
+
+	stat, err := s.data.Stat()
+	if err != nil {
+		return err
+	}
+
+	// Sector 0 is never used, so that a sector of 0 can mark the end of a
+	// chain.
+	end := max((stat.Size()+SectorLen-1)/SectorLen, 1)
+
+	n := dataLen(group)
+	sectors := make([]uint32, max((len(data)+n-1)/n, 1))
+	for i := range sectors {
+		if i < len(reuse) {
+			sectors[i] = reuse[i]
+		} else {
+			if end > maxSector {
+				return errors.New("cache: data file full")
+			}
+			sectors[i] = uint32(end)
+			end++
+		}
+	}
+
+	b := jagbuf.NewWithCapacity(SectorLen)
+	for i, sector := range sectors {
+		h := sectorHeader{group: group, chunk: uint16(i), archive: archive}
+		if i+1 < len(sectors) {
+			h.next = sectors[i+1]
+		}
+
+		b.Reset()
+		h.write(b)
+		b.Write(data[i*n : min((i+1)*n, len(data))])
+
+		if _, err := s.data.WriteAt(b.Bytes(), int64(sector)*SectorLen); err != nil {
+			return err
+		}
+	}
+
+	b.Reset()
+	indexEntry{size: uint32(len(data)), sector: sectors[0]}.write(b)
+	_, err = index.WriteAt(b.Bytes(), int64(group)*IndexEntryLen)
+	return err
+}
+
+// index returns the index file of an archive, creating it if needed.
+func (s *Store) index(archive uint8) (*os.File, error) {
+	if s.indexes[archive] == nil {
+		f, err := os.OpenFile(filepath.Join(s.dir, IndexFileName(archive)), os.O_RDWR|os.O_CREATE, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		s.indexes[archive] = f
+	}
+	return s.indexes[archive], nil
+}
+
+// entry returns the index entry of a group, failing for groups outside the
+// range of a sector header.
+func (s *Store) entry(archive uint8, group int) (indexEntry, error) {
+	if group < 0 || group > maxGroup {
+		return indexEntry{}, fmt.Errorf("cache: group %d out of range", group)
+	}
+
+	f := s.indexes[archive]
+	if f == nil {
+		return indexEntry{}, ErrNotFound
+	}
+
+	var buf [IndexEntryLen]byte
+	if _, err := f.ReadAt(buf[:], int64(group)*IndexEntryLen); err != nil {
+		if errors.Is(err, io.EOF) {
+			return indexEntry{}, ErrNotFound
+		}
+		return indexEntry{}, err
+	}
+
+	e := readIndexEntry(jagbuf.Wrap(buf[:]))
+	if e.sector == 0 {
+		return indexEntry{}, ErrNotFound
+	}
+	return e, nil
+}
+
+// walk follows the chain of sectors of a group, checking the header of each
+// and calling fn with the sector and the part of the group it holds.
+func (s *Store) walk(archive uint8, group int, e indexEntry, fn func(sector uint32, chunk []byte)) error {
+	corrupt := func(sector uint32, reason any) error {
+		return fmt.Errorf("%w: archive %d group %d sector %d: %v", ErrCorrupt, archive, group, sector, reason)
+	}
+
+	headerLen, n := headerLen(group), dataLen(group)
+	buf := make([]byte, SectorLen)
+
+	sector := e.sector
+	remaining := int(e.size)
+	for chunk := 0; remaining > 0; chunk++ {
+		if sector == 0 {
+			return corrupt(sector, "chain ends early")
+		}
+
+		sectorLen := headerLen + min(remaining, n)
+		if _, err := s.data.ReadAt(buf[:sectorLen], int64(sector)*SectorLen); err != nil {
+			if errors.Is(err, io.EOF) {
+				return corrupt(sector, "sector past the end of the data file")
+			}
+			return err
+		}
+
+		h := readSectorHeader(jagbuf.Wrap(buf[:headerLen]), group)
+		if err := h.check(archive, group, uint16(chunk)); err != nil {
+			return corrupt(sector, err)
+		}
+
+		fn(sector, buf[headerLen:sectorLen])
+		remaining -= sectorLen - headerLen
+		sector = h.next
+	}
+
+	return nil
+}
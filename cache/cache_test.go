@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func create(t *testing.T) (*Store, string) {
+	t.Helper()
+
+	dir := filepath.Join(t.TempDir(), "cache")
+	s, err := Create(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+
+	return s, dir
+}
+
+func dataSize(t *testing.T, dir string) int64 {
+	t.Helper()
+
+	stat, err := os.Stat(filepath.Join(dir, DataFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stat.Size()
+}
+
+func TestStore_ReadWrite(t *testing.T) {
+	s, dir := create(t)
+
+	groups := map[int][]byte{
+		0:      []byte("small group"),
+		1:      {},
+		7:      bytes.Repeat([]byte("multiple sectors "), 100),
+		70000:  bytes.Repeat([]byte("extended header "), 100),
+		0xFFFF: bytes.Repeat([]byte{1}, 512),
+	}
+
+	for group, data := range groups {
+		if err := s.Write(2, group, data); err != nil {
+			t.Fatalf("Write fail: group %d: %v", group, err)
+		}
+	}
+	if err := s.Write(ReferenceArchive, 2, []byte("reference table")); err != nil {
+		t.Fatal(err)
+	}
+
+	// Reopen the cache to read everything back from disk.
+	s.Close()
+	s, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for group, data := range groups {
+		read, err := s.Read(2, group)
+		if err != nil {
+			t.Fatalf("Read fail: group %d: %v", group, err)
+		}
+		if !bytes.Equal(read, data) {
+			t.Errorf("Read fail: group %d: expected %d bytes, got %d", group, len(data), len(read))
+		}
+		if err := s.Validate(2, group); err != nil {
+			t.Errorf("Validate fail: group %d: %v", group, err)
+		}
+	}
+
+	if archives := s.Archives(); !reflect.DeepEqual(archives, []uint8{2, ReferenceArchive}) {
+		t.Errorf("Archives fail: expected [2 255], got %v", archives)
+	}
+
+	list, err := s.Groups(2)
+	if err != nil || !reflect.DeepEqual(list, []int{0, 1, 7, 0xFFFF, 70000}) {
+		t.Errorf("Groups fail: expected [0 1 7 65535 70000], got %v, %v", list, err)
+	}
+}
+
+func TestStore_Layout(t *testing.T) {
+	s, dir := create(t)
+
+	if err := s.Write(3, 1, []byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+
+	index, _ := os.ReadFile(filepath.Join(dir, IndexFileName(3)))
+	expected := []byte{0, 0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 1}
+	if !bytes.Equal(index, expected) {
+		t.Errorf("Write fail: expected index %x, got %x", expected, index)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, DataFileName))
+	expected = []byte{0, 1, 0, 0, 0, 0, 0, 3, 'a', 'b', 'c'}
+	if !bytes.Equal(data[SectorLen:], expected) {
+		t.Errorf("Write fail: expected sector %x, got %x", expected, data[SectorLen:])
+	}
+}
+
+func TestStore_Overwrite(t *testing.T) {
+	s, dir := create(t)
+
+	large := bytes.Repeat([]byte{'a'}, 5*512)
+	if err := s.Write(0, 0, large); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Write(0, 1, []byte("after")); err != nil {
+		t.Fatal(err)
+	}
+	size := dataSize(t, dir)
+
+	// A smaller group fits in the sectors it already has.
+	small := bytes.Repeat([]byte{'b'}, 3*512)
+	if err := s.Write(0, 0, small); err != nil {
+		t.Fatal(err)
+	}
+	if dataSize(t, dir) != size {
+		t.Errorf("Write fail: expected the data file to stay %d bytes, got %d", size, dataSize(t, dir))
+	}
+
+	// A larger one reuses the 3 sectors it now has, and appends 4 more
+	// after those of group 1.
+	larger := bytes.Repeat([]byte{'c'}, 7*512)
+	if err := s.Write(0, 0, larger); err != nil {
+		t.Fatal(err)
+	}
+	if expected := int64(11 * SectorLen); dataSize(t, dir) != expected {
+		t.Errorf("Write fail: expected the data file to be %d bytes, got %d", expected, dataSize(t, dir))
+	}
+
+	for group, data := range map[int][]byte{0: larger, 1: []byte("after")} {
+		if read, err := s.Read(0, group); err != nil || !bytes.Equal(read, data) {
+			t.Errorf("Read fail: group %d did not read back: %v", group, err)
+		}
+	}
+}
+
+func TestStore_NotFound(t *testing.T) {
+	s, _ := create(t)
+
+	if err := s.Write(0, 5, []byte("data")); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		archive uint8
+		group   int
+	}{{0, 0}, {0, 6}, {0, 1000}, {1, 5}} {
+		if _, err := s.Read(test.archive, test.group); !errors.Is(err, ErrNotFound) {
+			t.Errorf("Read fail: archive %d group %d: expected ErrNotFound, got %v", test.archive, test.group, err)
+		}
+	}
+
+	if _, err := s.Read(0, -1); err == nil {
+		t.Errorf("Read fail: expected an error for group -1")
+	}
+	if math.MaxInt > maxGroup {
+		last := int64(maxGroup)
+		group := int(last + 1)
+		if err := s.Write(0, group, []byte("data")); err == nil {
+			t.Errorf("Write fail: expected an error for group %d", group)
+		}
+		if _, err := s.Read(0, group); err == nil || errors.Is(err, ErrNotFound) {
+			t.Errorf("Read fail: expected a range error for group %d, got %v", group, err)
+		}
+	}
+}
+
+func TestStore_Corrupt(t *testing.T) {
+	tests := map[string]func(data []byte){
+		"archive":  func(data []byte) { data[2*SectorLen+7] = 9 },
+		"group":    func(data []byte) { data[2*SectorLen+1] = 9 },
+		"chunk":    func(data []byte) { data[2*SectorLen+3] = 9 },
+		"end":      func(data []byte) { data[SectorLen+4], data[SectorLen+5], data[SectorLen+6] = 0, 0, 0 },
+		"past end": func(data []byte) { data[SectorLen+4] = 0xFF },
+	}
+
+	for name, corrupt := range tests {
+		s, dir := create(t)
+		if err := s.Write(0, 0, bytes.Repeat([]byte{'x'}, 1000)); err != nil {
+			t.Fatal(err)
+		}
+
+		path := filepath.Join(dir, DataFileName)
+		data, _ := os.ReadFile(path)
+		corrupt(data)
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := s.Read(0, 0); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Read fail: %s: expected ErrCorrupt, got %v", name, err)
+		}
+		if err := s.Validate(0, 0); !errors.Is(err, ErrCorrupt) {
+			t.Errorf("Validate fail: %s: expected ErrCorrupt, got %v", name, err)
+		}
+
+		// Writing the group again replaces the broken part of the chain.
+		if err := s.Write(0, 0, []byte("fixed")); err != nil {
+			t.Fatal(err)
+		}
+		if read, err := s.Read(0, 0); err != nil || string(read) != "fixed" {
+			t.Errorf("Write fail: %s: expected a corrupt group to be rewritten, got %q, %v", name, read, err)
+		}
+	}
+}
+
+func TestOpen_Missing(t *testing.T) {
+	if _, err := Open(t.TempDir()); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Open fail: expected os.ErrNotExist, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package cache
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+const (
+	// IndexEntryLen is the length of an entry in an .idx file.
+	IndexEntryLen = 6
+
+	// SectorLen is the length of a sector in the .dat2 file, including its
+	// header.
+	SectorLen = 520
+
+	sectorHeaderLen         = 8
+	extendedSectorHeaderLen = 10
+
+	// MaxGroupLen is the longest group an index entry can hold.
+	MaxGroupLen = 0xFFFFFF
+	// maxSector is the last sector an index entry or sector header can
+	// point to.
+	maxSector = 0xFFFFFF
+
+	// maxShortGroup is the last group with a short sector header. Larger
+	// groups have 4 byte ids, leaving less room for data.
+	maxShortGroup = 0xFFFF
+	// maxGroup is the last group a long sector header can name, as the
+	// client reads its id as a signed 32 bit integer.
+	maxGroup = math.MaxInt32
+)
+
+// indexEntry locates a group in the .dat2 file. A sector of 0 marks a group
+// that is not in the cache, as the first sector is never used.
+type indexEntry struct {
+	size   uint32
+	sector uint32
+}
+
+func readIndexEntry(b *jagbuf.Buffer) indexEntry {
+	d := jagbuf.NewDecoder(b)
+	return indexEntry{size: d.ReadUint24(), sector: d.ReadUint24()}
+}
+
+func (e indexEntry) write(b *jagbuf.Buffer) {
+	b.WriteUint24(e.size)
+	b.WriteUint24(e.sector)
+}
+
+// sectorHeader is the header in front of the data in every sector, linking
+// the sectors of a group into a chain.
+type sectorHeader struct {
+	group   int
+	chunk   uint16
+	next    uint32
+	archive uint8
+}
+
+// extended reports whether a group has the longer sector header.
+func extended(group int) bool {
+	return group > maxShortGroup
+}
+
+// headerLen returns the length of the sector headers of a group.
+func headerLen(group int) int {
+	if extended(group) {
+		return extendedSectorHeaderLen
+	}
+	return sectorHeaderLen
+}
+
+// dataLen returns the length of the data in each full sector of a group.
+func dataLen(group int) int {
+	return SectorLen - headerLen(group)
+}
+
+// readSectorHeader reads the header of a sector of group, which decides
+// its layout. b must hold the whole header.
+func readSectorHeader(b *jagbuf.Buffer, group int) sectorHeader {
+	d := jagbuf.NewDecoder(b)
+
+	var h sectorHeader
+	if extended(group) {
+		h.group = int(d.ReadUint32())
+	} else {
+		h.group = int(d.ReadUint16())
+	}
+	h.chunk = d.ReadUint16()
+	h.next = d.ReadUint24()
+	h.archive = d.ReadUint8()
+
+	return h
+}
+
+func (h sectorHeader) write(b *jagbuf.Buffer) {
+	if extended(h.group) {
+		b.WriteUint32(uint32(h.group))
+	} else {
+		b.WriteUint16(uint16(h.group))
+	}
+	b.WriteUint16(h.chunk)
+	b.WriteUint24(h.next)
+	b.WriteUint8(h.archive)
+}
+
+// check returns an error if the header is not the one expected for a chunk
+// of a group.
+func (h sectorHeader) check(archive uint8, group int, chunk uint16) error {
+	switch {
+	case h.archive != archive:
+		return fmt.Errorf("archive %d, expected %d", h.archive, archive)
+	case h.group != group:
+		return fmt.Errorf("group %d, expected %d", h.group, group)
+	case h.chunk != chunk:
+		return fmt.Errorf("chunk %d, expected %d", h.chunk, chunk)
+	}
+	return nil
+}
@@ -0,0 +1,736 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/apogee-rs/jagbuf"
+	"github.com/apogee-rs/jagbuf/internal/tag"
+)
+
+// source is what parseFile finds in a Go source file.
+type source struct {
+	pkg     string
+	packets []packet
+	// named maps the types declared in the file as another type by name,
+	// such as type Opcode uint8, to that type.
+	named map[string]string
+	// imports maps the names the file imports packages as to the import,
+	// for the types of other packages used in conversions.
+	imports map[string]importSpec
+}
+
+type importSpec struct {
+	path string
+	// name is the name the package is explicitly imported as, if any.
+	name string
+}
+
+// packet is a struct type to generate methods for.
+type packet struct {
+	name   string
+	fields []field
+}
+
+type field struct {
+	name string
+	typ  ast.Expr
+	spec *tag.Spec
+}
+
+// basicTypes are the predeclared types that always need a jag tag.
+var basicTypes = []string{
+	"bool", "byte", "complex64", "complex128", "float32", "float64",
+	"int", "int8", "int16", "int32", "int64", "rune", "string",
+	"uint", "uint8", "uint16", "uint32", "uint64", "uintptr",
+}
+
+// parseFile finds the structs in a Go source file to generate methods for.
+// If names is empty every struct with at least one jag tag is used.
+func parseFile(filename string, src []byte, names []string) (*source, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), filename, src, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &source{pkg: file.Name.Name, named: map[string]string{}, imports: map[string]importSpec{}}
+	for _, imp := range file.Imports {
+		path, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		spec := importSpec{path: path}
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			spec.name, name = imp.Name.Name, imp.Name.Name
+		}
+		out.imports[name] = spec
+	}
+
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.TYPE {
+			continue
+		}
+
+		for _, s := range gen.Specs {
+			spec := s.(*ast.TypeSpec)
+			if ident, ok := spec.Type.(*ast.Ident); ok {
+				out.named[spec.Name.Name] = ident.Name
+			}
+
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok || (len(names) > 0 && !slices.Contains(names, spec.Name.Name)) {
+				continue
+			}
+
+			p, tagged, err := parseStruct(spec.Name.Name, st)
+			if err != nil {
+				return nil, err
+			}
+
+			if tagged || len(names) > 0 {
+				out.packets = append(out.packets, p)
+			}
+		}
+	}
+
+	for _, name := range names {
+		if !slices.ContainsFunc(out.packets, func(p packet) bool { return p.name == name }) {
+			return nil, fmt.Errorf("struct %s not found in %s", name, filename)
+		}
+	}
+
+	return out, nil
+}
+
+// parseStruct reads the tags of a struct, following the same rules as
+// jagbuf.Marshal. It also reports whether any field has a jag tag.
+func parseStruct(name string, st *ast.StructType) (packet, bool, error) {
+	p := packet{name: name}
+	anyTagged := false
+
+	for _, f := range st.Fields.List {
+		var jagTag string
+		var tagged bool
+		if f.Tag != nil {
+			raw, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return p, false, err
+			}
+			jagTag, tagged = reflect.StructTag(raw).Lookup("jag")
+		}
+		anyTagged = anyTagged || tagged
+
+		names := f.Names
+		if len(names) == 0 {
+			// Embedded fields are named after their type.
+			names = []*ast.Ident{ast.NewIdent(typeName(f.Type))}
+		}
+
+		for _, ident := range names {
+			if !ident.IsExported() || jagTag == "-" {
+				continue
+			}
+
+			spec, err := tag.Parse(jagTag)
+			if err == nil && spec.Kind == tag.Struct && !mayBeStruct(f.Type) {
+				if !tagged {
+					err = errors.New("missing jag tag")
+				} else {
+					err = errors.New("missing wire type")
+				}
+			}
+			if err != nil {
+				return p, false, fmt.Errorf("%s.%s: %w", name, ident.Name, err)
+			}
+
+			p.fields = append(p.fields, field{name: ident.Name, typ: f.Type, spec: spec})
+		}
+	}
+
+	return p, anyTagged, nil
+}
+
+func typeName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return typeName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return types.ExprString(expr)
+}
+
+// mayBeStruct reports whether expr could name a struct type, or a slice or
+// array of them. Without type checking only predeclared types can be ruled
+// out, anything else is assumed to have generated methods of its own.
+func mayBeStruct(expr ast.Expr) bool {
+	if array, ok := expr.(*ast.ArrayType); ok {
+		expr = array.Elt
+	}
+
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return !slices.Contains(basicTypes, t.Name)
+	case *ast.SelectorExpr:
+		return true
+	}
+	return false
+}
+
+// generator writes the source of the generated file.
+type generator struct {
+	buf     bytes.Buffer
+	imports map[string]string // path to explicit name
+	named   map[string]string
+	sources map[string]importSpec
+
+	// field names the field being generated in the errors of range checks.
+	field string
+}
+
+func generate(src *source, args []string) ([]byte, error) {
+	body := &generator{
+		imports: map[string]string{"github.com/apogee-rs/jagbuf": ""},
+		named:   src.named,
+		sources: src.imports,
+	}
+	for _, p := range src.packets {
+		if err := body.packet(p); err != nil {
+			return nil, err
+		}
+	}
+
+	// Standard library imports go first, in a group of their own.
+	var std, other []string
+	for path, name := range body.imports {
+		spec := strconv.Quote(path)
+		if name != "" {
+			spec = name + " " + spec
+		}
+
+		if strings.Contains(strings.Split(path, "/")[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+
+	imports := strings.Join(std, "\n")
+	if len(std) > 0 {
+		imports += "\n\n"
+	}
+	imports += strings.Join(other, "\n")
+
+	out := &bytes.Buffer{}
+	fmt.Fprintf(out, "// Code generated by \"jagbufgen %s\"; DO NOT EDIT.\n\n", strings.Join(args, " "))
+	fmt.Fprintf(out, "package %s\n\n", src.pkg)
+	fmt.Fprintf(out, "import (\n%s\n)\n", imports)
+	out.Write(body.buf.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %w", err)
+	}
+
+	return formatted, nil
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.buf, format, args...)
+}
+
+// receiverName picks a receiver that cannot clash with the buffer parameter
+// or the variables used in the generated code.
+func receiverName(typeName string) string {
+	name := strings.ToLower(typeName[:1])
+	if slices.Contains([]string{"b", "i", "j", "k", "l", "n", "v"}, name) {
+		return "p"
+	}
+	return name
+}
+
+func (g *generator) packet(p packet) error {
+	recv := receiverName(p.name)
+
+	g.printf("\n// Encode writes %s to b, in the layout described by its jag tags.\n", p.name)
+	g.printf("func (%s *%s) Encode(b *jagbuf.Buffer) error {\n", recv, p.name)
+	for _, f := range p.fields {
+		g.field = p.name + "." + f.name
+		if err := g.encode(recv+"."+f.name, f.typ, f.spec, 0); err != nil {
+			return fmt.Errorf("%s.%s: %w", p.name, f.name, err)
+		}
+	}
+	g.printf("return nil\n}\n")
+
+	g.printf("\n// Decode reads %s from b, in the layout described by its jag tags.\n", p.name)
+	g.printf("func (%s *%s) Decode(b *jagbuf.Buffer) error {\n", recv, p.name)
+	for _, f := range p.fields {
+		g.field = p.name + "." + f.name
+		if err := g.decode(recv+"."+f.name, f.typ, f.spec, 0); err != nil {
+			return fmt.Errorf("%s.%s: %w", p.name, f.name, err)
+		}
+	}
+	g.printf("return nil\n}\n")
+
+	return nil
+}
+
+// loopVar returns the index variable for a loop nested depth levels deep.
+func loopVar(depth int) string {
+	return string(rune('i' + depth))
+}
+
+// isArray reports whether expr is a fixed length array type, and isSlice
+// whether it is a slice type.
+func isArray(expr ast.Expr) bool {
+	array, ok := expr.(*ast.ArrayType)
+	return ok && array.Len != nil
+}
+
+func isSlice(expr ast.Expr) bool {
+	array, ok := expr.(*ast.ArrayType)
+	return ok && array.Len == nil
+}
+
+// convert wraps expr in a conversion to typ, unless it already has that
+// type.
+func convert(typ string, expr string, exprType string) string {
+	if typ == exprType {
+		return expr
+	}
+	return typ + "(" + expr + ")"
+}
+
+// useType imports the packages of the types in typ, for when typ is named in
+// the generated code.
+func (g *generator) useType(typ ast.Expr) {
+	ast.Inspect(typ, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if pkg, ok := sel.X.(*ast.Ident); ok {
+				if imp, ok := g.sources[pkg.Name]; ok {
+					g.imports[imp.path] = imp.name
+				}
+			}
+		}
+		return true
+	})
+}
+
+func (g *generator) encode(expr string, typ ast.Expr, spec *tag.Spec, depth int) error {
+	typeStr := types.ExprString(typ)
+
+	if spec.Kind == tag.Bytes {
+		switch {
+		case isArray(typ):
+			if spec.Length != nil {
+				return errors.New("len is not valid for arrays")
+			}
+			g.printf("b.Write(%s[:])\n", expr)
+		case isSlice(typ):
+			if spec.Length != nil {
+				g.writeCount(spec.Length, "len("+expr+")")
+			}
+			g.printf("b.Write(%s)\n", expr)
+		default:
+			return fmt.Errorf("bytes cannot be stored in %s", typeStr)
+		}
+		return nil
+	}
+
+	if array, ok := typ.(*ast.ArrayType); ok {
+		if array.Len == nil && spec.Length == nil {
+			return errors.New("slices need a len option")
+		}
+		if array.Len != nil && spec.Length != nil {
+			return errors.New("len is not valid for arrays")
+		}
+		if spec.Length != nil {
+			g.writeCount(spec.Length, "len("+expr+")")
+		}
+
+		i := loopVar(depth)
+		g.printf("for %s := range %s {\n", i, expr)
+		if err := g.encode(expr+"["+i+"]", array.Elt, spec.Element(), depth+1); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	}
+
+	switch spec.Kind {
+	case tag.Int, tag.Smart:
+		g.writeInt(spec, expr, typeStr)
+	case tag.String:
+		if spec.Jag {
+			g.printf("b.WriteUint8(0)\n")
+		}
+		if spec.CP1252 {
+			g.printf("b.Write(jagbuf.EncodeCP1252(%s))\n", convert("string", expr, typeStr))
+			g.printf("b.WriteUint8(0)\n")
+		} else {
+			g.printf("b.WriteString(%s)\n", convert("string", expr, typeStr))
+		}
+	case tag.Struct:
+		g.printf("if err := %s.Encode(b); err != nil {\nreturn err\n}\n", expr)
+	}
+
+	return nil
+}
+
+func (g *generator) decode(expr string, typ ast.Expr, spec *tag.Spec, depth int) error {
+	typeStr := types.ExprString(typ)
+
+	if spec.Kind == tag.Bytes {
+		switch {
+		case isArray(typ):
+			if spec.Length != nil {
+				return errors.New("len is not valid for arrays")
+			}
+			g.printf("if err := b.ReadBytes(%s[:]); err != nil {\nreturn err\n}\n", expr)
+		case isSlice(typ):
+			g.useType(typ)
+			if spec.Length != nil {
+				g.printf("{\n")
+				g.readCount(spec.Length)
+				g.printf("%s = make(%s, n)\n}\n", expr, typeStr)
+			} else {
+				g.printf("%s = make(%s, b.ReadableBytes())\n", expr, typeStr)
+			}
+			g.printf("if err := b.ReadBytes(%s); err != nil {\nreturn err\n}\n", expr)
+		default:
+			return fmt.Errorf("bytes cannot be stored in %s", typeStr)
+		}
+		return nil
+	}
+
+	if array, ok := typ.(*ast.ArrayType); ok {
+		if array.Len == nil && spec.Length == nil {
+			return errors.New("slices need a len option")
+		}
+		if array.Len != nil && spec.Length != nil {
+			return errors.New("len is not valid for arrays")
+		}
+		if spec.Length != nil {
+			g.useType(typ)
+			g.printf("{\n")
+			g.readCount(spec.Length)
+			g.printf("%s = make(%s, n)\n}\n", expr, typeStr)
+		}
+
+		i := loopVar(depth)
+		g.printf("for %s := range %s {\n", i, expr)
+		if err := g.decode(expr+"["+i+"]", array.Elt, spec.Element(), depth+1); err != nil {
+			return err
+		}
+		g.printf("}\n")
+		return nil
+	}
+
+	switch spec.Kind {
+	case tag.Int, tag.Smart:
+		method, valueType := readMethod(spec)
+		g.useType(typ)
+		g.openScope(depth)
+		g.printf("v, err := b.%s\nif err != nil {\nreturn err\n}\n", method)
+		g.printf("%s = %s\n", expr, convert(typeStr, "v", valueType))
+		g.checkOverflow(spec, expr, typeStr, valueType)
+		g.closeScope(depth)
+	case tag.String:
+		method := "ReadString()"
+		if spec.Jag {
+			method = "ReadJagString()"
+		}
+		g.useType(typ)
+		g.openScope(depth)
+		g.printf("v, err := b.%s\nif err != nil {\nreturn err\n}\n", method)
+		if spec.CP1252 {
+			g.printf("%s = %s\n", expr, convert(typeStr, "jagbuf.DecodeCP1252([]byte(v))", "string"))
+		} else {
+			g.printf("%s = %s\n", expr, convert(typeStr, "v", "string"))
+		}
+		g.closeScope(depth)
+	case tag.Struct:
+		g.printf("if err := %s.Decode(b); err != nil {\nreturn err\n}\n", expr)
+	}
+
+	return nil
+}
+
+// openScope opens a block for the variables of a single value, unless it is
+// the only statement in the body of a loop.
+func (g *generator) openScope(depth int) {
+	if depth == 0 {
+		g.printf("{\n")
+	}
+}
+
+func (g *generator) closeScope(depth int) {
+	if depth == 0 {
+		g.printf("}\n")
+	}
+}
+
+// readCount reads a count prefix into n, rejecting counts larger than the
+// remaining data before anything is allocated, as jagbuf.Unmarshal does.
+func (g *generator) readCount(spec *tag.Spec) {
+	g.imports["io"] = ""
+
+	method, valueType := readMethod(spec)
+	g.printf("n, err := b.%s\nif err != nil {\nreturn err\n}\n", method)
+	if spec.Signed {
+		g.printf("if n < 0 || int64(n) > int64(b.ReadableBytes()) {\n")
+	} else {
+		g.printf("if %s > uint64(b.ReadableBytes()) {\n", convert("uint64", "n", valueType))
+	}
+	g.printf("return io.EOF\n}\n")
+}
+
+func (g *generator) writeInt(spec *tag.Spec, expr string, exprType string) {
+	g.checkRange(spec, expr, exprType)
+
+	method, argType := writeMethod(spec)
+	g.printf(method+"\n", convert(argType, expr, exprType))
+}
+
+// writeCount writes the length expr as a count prefix, failing like
+// jagbuf.Marshal if it is too long for spec.
+func (g *generator) writeCount(spec *tag.Spec, expr string) {
+	if _, hi := spec.Range(); hi < math.MaxInt32 {
+		g.imports["fmt"] = ""
+		g.printf("if %s > %d {\nreturn fmt.Errorf(\"%s: length %%d out of range\", %s)\n}\n", expr, hi, g.field, expr)
+	} else if hi < math.MaxInt64 {
+		g.imports["fmt"] = ""
+		g.printf("if uint64(%s) > %d {\nreturn fmt.Errorf(\"%s: length %%d out of range\", %s)\n}\n", expr, hi, g.field, expr)
+	}
+
+	method, argType := writeMethod(spec)
+	g.printf(method+"\n", convert(argType, expr, "int"))
+}
+
+// checkRange fails like jagbuf.Marshal if expr, of type exprType, is out of
+// the range of spec. Only the bounds exprType can exceed are checked, so
+// nothing is checked for a type that always fits.
+func (g *generator) checkRange(spec *tag.Spec, expr string, exprType string) {
+	lo, hi := spec.Range()
+	typeLo, typeHi, known := g.intRange(exprType, false)
+
+	// Constants outside the 32-bit range do not fit int, uint or uintptr on
+	// every platform, so those are compared after widening them.
+	compared := func(c int64) string {
+		if !known || !platformSized(g.resolve(exprType)) || (c >= math.MinInt32 && c <= math.MaxInt32) {
+			return expr
+		}
+		if typeLo < 0 {
+			return "int64(" + expr + ")"
+		}
+		return "uint64(" + expr + ")"
+	}
+
+	var conds []string
+	switch {
+	case known && typeLo >= lo:
+	case lo == 0:
+		conds = append(conds, expr+" < 0")
+	case known:
+		conds = append(conds, fmt.Sprintf("%s < %d", compared(lo), lo))
+	case lo != math.MinInt64:
+		// The type is unknown, so only negative values are compared as
+		// int64, as they are the only ones that convert exactly.
+		conds = append(conds, fmt.Sprintf("(%s < 0 && int64(%s) < %d)", expr, expr, lo))
+	}
+	switch {
+	case known && typeHi <= hi:
+	case known:
+		conds = append(conds, fmt.Sprintf("%s > %d", compared(int64(min(hi, math.MaxInt64))), hi))
+	case hi != math.MaxUint64:
+		conds = append(conds, fmt.Sprintf("(%s > 0 && uint64(%s) > %d)", expr, expr, hi))
+	}
+
+	if len(conds) == 0 {
+		return
+	}
+
+	g.imports["fmt"] = ""
+	g.printf("if %s {\nreturn fmt.Errorf(\"%s: value %%d out of range\", %s)\n}\n", strings.Join(conds, " || "), g.field, expr)
+}
+
+// checkOverflow fails like jagbuf.Unmarshal if v, of type valueType and in
+// the range of spec, did not fit expr, of type exprType, which it has just
+// been converted to. Nothing is checked for a type that always fits.
+func (g *generator) checkOverflow(spec *tag.Spec, expr string, exprType string, valueType string) {
+	lo, hi := spec.Range()
+	typeLo, typeHi, known := g.intRange(exprType, true)
+	if known && typeLo <= lo && typeHi >= hi {
+		return
+	}
+
+	// Converting back gives v unless bits were lost, or the sign changed
+	// between signed and unsigned types of the same width.
+	cond := convert(valueType, expr, exprType) + " != v"
+	switch {
+	case known && (typeLo < 0) == spec.Signed:
+	case known && spec.Signed:
+		cond += " || v < 0"
+	case spec.Signed:
+		cond += fmt.Sprintf(" || (%s < 0) != (v < 0)", expr)
+	default:
+		cond += fmt.Sprintf(" || %s < 0", expr)
+	}
+
+	g.imports["fmt"] = ""
+	g.printf("if %s {\nreturn fmt.Errorf(\"%s: value %%d overflows %s\", v)\n}\n", cond, g.field, exprType)
+}
+
+// intBits is the width of each predeclared integer type, with 0 for those
+// whose width depends on the platform, and whether it is signed.
+var intBits = map[string]struct {
+	bits   int
+	signed bool
+}{
+	"int8": {8, true}, "int16": {16, true}, "int32": {32, true}, "rune": {32, true}, "int64": {64, true}, "int": {0, true},
+	"uint8": {8, false}, "byte": {8, false}, "uint16": {16, false}, "uint32": {32, false}, "uint64": {64, false},
+	"uint": {0, false}, "uintptr": {0, false},
+}
+
+func platformSized(typ string) bool {
+	info, ok := intBits[typ]
+	return ok && info.bits == 0
+}
+
+// resolve follows the types declared in the file as another type by name
+// from typ, returning the predeclared type it ends at or "" if there is
+// none.
+func (g *generator) resolve(typ string) string {
+	for range len(g.named) + 1 {
+		if _, ok := intBits[typ]; ok {
+			return typ
+		}
+
+		next, ok := g.named[typ]
+		if !ok {
+			break
+		}
+		typ = next
+	}
+	return ""
+}
+
+// intRange returns the range of the integer type typ, reporting false if
+// it is not known to be one. The width of int, uint and uintptr is taken as
+// 32 bits when narrowest is set, and as 64 bits otherwise.
+func (g *generator) intRange(typ string, narrowest bool) (int64, uint64, bool) {
+	info, ok := intBits[g.resolve(typ)]
+	if !ok {
+		return 0, 0, false
+	}
+
+	bits := info.bits
+	if bits == 0 {
+		bits = 64
+		if narrowest {
+			bits = 32
+		}
+	}
+
+	if info.signed {
+		return math.MinInt64 >> (64 - bits), math.MaxInt64 >> (64 - bits), true
+	}
+	return 0, math.MaxUint64 >> (64 - bits), true
+}
+
+var orderNames = []string{"BigEndian", "LittleEndian", "MiddleEndianV1", "MiddleEndianV2"}
+var orderSuffixes = []string{"", "LE", "V1", "V2"}
+var transformNames = []string{"TransformNone", "TransformAdd", "TransformNeg", "TransformMirror"}
+var transformSuffixes = []string{"", "_Sub", "_Neg", "_Mirror"}
+
+// intType returns the type name a fixed width Buffer method uses for an
+// integer spec, such as "Uint16" for "u16".
+func intType(spec *tag.Spec) string {
+	if spec.Signed {
+		return "Int" + strconv.Itoa(spec.Width*8)
+	}
+	return "Uint" + strconv.Itoa(spec.Width*8)
+}
+
+// valueType returns the Go type of the values a fixed width Buffer method
+// reads or writes.
+func valueType(spec *tag.Spec) string {
+	bits := spec.Width * 8
+	if spec.Width == 3 {
+		bits = 32
+	}
+	if spec.Signed {
+		return "int" + strconv.Itoa(bits)
+	}
+	return "uint" + strconv.Itoa(bits)
+}
+
+var bufferType = reflect.TypeOf(&jagbuf.Buffer{})
+
+// hasMethod reports whether Buffer has a method with the given name.
+func hasMethod(name string) bool {
+	_, ok := bufferType.MethodByName(name)
+	return ok
+}
+
+// readMethod returns the Buffer method call that reads an integer spec and
+// the type it returns. A dedicated method is used when the package has one,
+// otherwise the general ReadUintN or ReadIntN.
+func readMethod(spec *tag.Spec) (string, string) {
+	if spec.Kind == tag.Smart {
+		if spec.Signed {
+			return "ReadSignedSmart()", "int16"
+		}
+		return "ReadSmart()", "uint16"
+	}
+
+	name := "Read" + intType(spec) + orderSuffixes[spec.Order] + transformSuffixes[spec.Transform]
+	if hasMethod(name) {
+		return name + "()", valueType(spec)
+	}
+
+	layout := fmt.Sprintf("%d, jagbuf.%s, jagbuf.%s", spec.Width, orderNames[spec.Order], transformNames[spec.Transform])
+	if spec.Signed {
+		return "ReadIntN(" + layout + ")", "int64"
+	}
+	return "ReadUintN(" + layout + ")", "uint64"
+}
+
+// writeMethod returns a format string for the Buffer method call that
+// writes an integer spec, and the type of its argument.
+func writeMethod(spec *tag.Spec) (string, string) {
+	if spec.Kind == tag.Smart {
+		if spec.Signed {
+			return "b.WriteSignedSmart(%s)", "int16"
+		}
+		return "b.WriteSmart(%s)", "uint16"
+	}
+
+	name := "Write" + intType(spec) + orderSuffixes[spec.Order] + transformSuffixes[spec.Transform]
+	if hasMethod(name) {
+		return "b." + name + "(%s)", valueType(spec)
+	}
+
+	layout := fmt.Sprintf("jagbuf.%s, jagbuf.%s", orderNames[spec.Order], transformNames[spec.Transform])
+	if spec.Signed {
+		return fmt.Sprintf("b.WriteIntN(%d, %%s, %s)", spec.Width, layout), "int64"
+	}
+	return fmt.Sprintf("b.WriteUintN(%d, %%s, %s)", spec.Width, layout), "uint64"
+}
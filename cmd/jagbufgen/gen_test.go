@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in internal/testpackets")
+
+func TestGenerate_Golden(t *testing.T) {
+	input := filepath.Join("internal", "testpackets", "packets.go")
+	golden := filepath.Join("internal", "testpackets", "packets_jag.go")
+
+	src, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := parseFile(input, src, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	generated, err := generate(parsed, []string{"packets.go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if *update {
+		if err := os.WriteFile(golden, generated, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(expected, generated) {
+		t.Errorf("generated code differs from %s, run go generate or go test -update", golden)
+	}
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing jag tag":       "type T struct { A int; B int `jag:\"u8\"` }",
+		"slices need a len":     "type T struct { A []int `jag:\"u8\"` }",
+		"len is not valid":      "type T struct { A [2]int `jag:\"u8,len=u8\"` }",
+		"unknown option":        "type T struct { A int `jag:\"u8,big\"` }",
+		"bytes cannot be":       "type T struct { A int `jag:\"bytes\"` }",
+		"struct Missing not":    "type T struct { A int `jag:\"u8\"` }",
+		"only valid for 32-bit": "type T struct { A int `jag:\"u16,v2\"` }",
+	}
+
+	for want, decl := range tests {
+		var names []string
+		if strings.Contains(want, "Missing") {
+			names = []string{"Missing"}
+		}
+
+		parsed, err := parseFile("test.go", []byte("package p\n"+decl), names)
+		if err == nil {
+			_, err = generate(parsed, nil)
+		}
+
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: expected an error containing %q, got %v", decl, want, err)
+		}
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{nil, {"a.go", "b.go"}} {
+		if err := run("", "", args); !errors.Is(err, errUsage) {
+			t.Errorf("run fail: %v: expected errUsage, got %v", args, err)
+		}
+	}
+}
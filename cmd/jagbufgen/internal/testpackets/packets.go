@@ -0,0 +1,73 @@
+// Package testpackets holds structs covering every jag tag, along with the
+// methods jagbufgen generates for them. Its tests check the generated
+// methods against jagbuf.Marshal and jagbuf.Unmarshal.
+package testpackets
+
+import "time"
+
+//go:generate go run github.com/apogee-rs/jagbuf/cmd/jagbufgen packets.go
+
+type Opcode uint8
+
+type Name string
+
+type Step struct {
+	DX int8 `jag:"i8"`
+	DY int8 `jag:"i8,neg"`
+}
+
+type Header struct {
+	Opcode Opcode `jag:"u8,add"`
+	Size   int    `jag:"u16"`
+}
+
+type Everything struct {
+	Header
+
+	U8       uint8  `jag:"u8"`
+	U8Neg    uint8  `jag:"u8,neg"`
+	I8Mirror int8   `jag:"i8,mirror"`
+	U16      uint16 `jag:"u16"`
+	U16Add   int    `jag:"u16,add"`
+	U16LE    uint16 `jag:"u16,le"`
+	U16LEAdd uint16 `jag:"u16,le,add"`
+	I16LE    int16  `jag:"i16,le"`
+	I16Neg   int16  `jag:"i16,neg"`
+	U24      uint32 `jag:"u24"`
+	I24LE    int32  `jag:"i24,le"`
+	U32      uint32 `jag:"u32"`
+	I32V1    int32  `jag:"i32,v1"`
+	U32V2    uint32 `jag:"u32,v2"`
+	U32LEAdd uint32 `jag:"u32,le,add"`
+	I64      int64  `jag:"i64"`
+	U64LE    uint64 `jag:"u64,le"`
+	Smart    int    `jag:"smart"`
+	SSmart   int16  `jag:"ssmart"`
+
+	Name     Name   `jag:"string"`
+	Title    string `jag:"jagstring,cp1252"`
+	Greeting string `jag:"string,cp1252"`
+
+	Path    []Step    `jag:"len=u8"`
+	Ids     []uint16  `jag:"u16,le,len=smart"`
+	Grid    [2][2]int `jag:"i8"`
+	Colours [3]int32  `jag:"i24"`
+	Payload []byte    `jag:"bytes,len=u16"`
+	Key     [4]byte   `jag:"bytes"`
+	Trailer []byte    `jag:"bytes"`
+
+	Cached int `jag:"-"`
+	local  int
+}
+
+// Narrow has fields that cannot hold every value of their wire type, or
+// hold values their wire type cannot, so the generated methods need range
+// checks.
+type Narrow struct {
+	U8     uint8         `jag:"u16"`
+	I8     int8          `jag:"smart"`
+	Opcode Opcode        `jag:"i16"`
+	U64    uint64        `jag:"i64"`
+	Int    int           `jag:"u32"`
+	Delay  time.Duration `jag:"i32"`
+}
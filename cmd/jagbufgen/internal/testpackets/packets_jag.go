@@ -0,0 +1,469 @@
+// Code generated by "jagbufgen packets.go"; DO NOT EDIT.
+
+package testpackets
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+// Encode writes Step to b, in the layout described by its jag tags.
+func (s *Step) Encode(b *jagbuf.Buffer) error {
+	b.WriteInt8(s.DX)
+	b.WriteIntN(1, int64(s.DY), jagbuf.BigEndian, jagbuf.TransformNeg)
+	return nil
+}
+
+// Decode reads Step from b, in the layout described by its jag tags.
+func (s *Step) Decode(b *jagbuf.Buffer) error {
+	{
+		v, err := b.ReadInt8()
+		if err != nil {
+			return err
+		}
+		s.DX = v
+	}
+	{
+		v, err := b.ReadInt8_Neg()
+		if err != nil {
+			return err
+		}
+		s.DY = v
+	}
+	return nil
+}
+
+// Encode writes Header to b, in the layout described by its jag tags.
+func (h *Header) Encode(b *jagbuf.Buffer) error {
+	b.WriteUintN(1, uint64(h.Opcode), jagbuf.BigEndian, jagbuf.TransformAdd)
+	if h.Size < 0 || h.Size > 65535 {
+		return fmt.Errorf("Header.Size: value %d out of range", h.Size)
+	}
+	b.WriteUint16(uint16(h.Size))
+	return nil
+}
+
+// Decode reads Header from b, in the layout described by its jag tags.
+func (h *Header) Decode(b *jagbuf.Buffer) error {
+	{
+		v, err := b.ReadUint8_Sub()
+		if err != nil {
+			return err
+		}
+		h.Opcode = Opcode(v)
+	}
+	{
+		v, err := b.ReadUint16()
+		if err != nil {
+			return err
+		}
+		h.Size = int(v)
+	}
+	return nil
+}
+
+// Encode writes Everything to b, in the layout described by its jag tags.
+func (e *Everything) Encode(b *jagbuf.Buffer) error {
+	if err := e.Header.Encode(b); err != nil {
+		return err
+	}
+	b.WriteUint8(e.U8)
+	b.WriteUintN(1, uint64(e.U8Neg), jagbuf.BigEndian, jagbuf.TransformNeg)
+	b.WriteIntN(1, int64(e.I8Mirror), jagbuf.BigEndian, jagbuf.TransformMirror)
+	b.WriteUint16(e.U16)
+	if e.U16Add < 0 || e.U16Add > 65535 {
+		return fmt.Errorf("Everything.U16Add: value %d out of range", e.U16Add)
+	}
+	b.WriteUintN(2, uint64(e.U16Add), jagbuf.BigEndian, jagbuf.TransformAdd)
+	b.WriteUint16LE(e.U16LE)
+	b.WriteUintN(2, uint64(e.U16LEAdd), jagbuf.LittleEndian, jagbuf.TransformAdd)
+	b.WriteInt16LE(e.I16LE)
+	b.WriteIntN(2, int64(e.I16Neg), jagbuf.BigEndian, jagbuf.TransformNeg)
+	if e.U24 > 16777215 {
+		return fmt.Errorf("Everything.U24: value %d out of range", e.U24)
+	}
+	b.WriteUint24(e.U24)
+	if e.I24LE < -8388608 || e.I24LE > 8388607 {
+		return fmt.Errorf("Everything.I24LE: value %d out of range", e.I24LE)
+	}
+	b.WriteInt24LE(e.I24LE)
+	b.WriteUint32(e.U32)
+	b.WriteInt32V1(e.I32V1)
+	b.WriteUint32V2(e.U32V2)
+	b.WriteUintN(4, uint64(e.U32LEAdd), jagbuf.LittleEndian, jagbuf.TransformAdd)
+	b.WriteInt64(e.I64)
+	b.WriteUint64LE(e.U64LE)
+	if e.Smart < 0 || e.Smart > 32767 {
+		return fmt.Errorf("Everything.Smart: value %d out of range", e.Smart)
+	}
+	b.WriteSmart(uint16(e.Smart))
+	if e.SSmart < -16384 || e.SSmart > 16383 {
+		return fmt.Errorf("Everything.SSmart: value %d out of range", e.SSmart)
+	}
+	b.WriteSignedSmart(e.SSmart)
+	b.WriteString(string(e.Name))
+	b.WriteUint8(0)
+	b.Write(jagbuf.EncodeCP1252(e.Title))
+	b.WriteUint8(0)
+	b.Write(jagbuf.EncodeCP1252(e.Greeting))
+	b.WriteUint8(0)
+	if len(e.Path) > 255 {
+		return fmt.Errorf("Everything.Path: length %d out of range", len(e.Path))
+	}
+	b.WriteUint8(uint8(len(e.Path)))
+	for i := range e.Path {
+		if err := e.Path[i].Encode(b); err != nil {
+			return err
+		}
+	}
+	if len(e.Ids) > 32767 {
+		return fmt.Errorf("Everything.Ids: length %d out of range", len(e.Ids))
+	}
+	b.WriteSmart(uint16(len(e.Ids)))
+	for i := range e.Ids {
+		b.WriteUint16LE(e.Ids[i])
+	}
+	for i := range e.Grid {
+		for j := range e.Grid[i] {
+			if e.Grid[i][j] < -128 || e.Grid[i][j] > 127 {
+				return fmt.Errorf("Everything.Grid: value %d out of range", e.Grid[i][j])
+			}
+			b.WriteInt8(int8(e.Grid[i][j]))
+		}
+	}
+	for i := range e.Colours {
+		if e.Colours[i] < -8388608 || e.Colours[i] > 8388607 {
+			return fmt.Errorf("Everything.Colours: value %d out of range", e.Colours[i])
+		}
+		b.WriteInt24(e.Colours[i])
+	}
+	if len(e.Payload) > 65535 {
+		return fmt.Errorf("Everything.Payload: length %d out of range", len(e.Payload))
+	}
+	b.WriteUint16(uint16(len(e.Payload)))
+	b.Write(e.Payload)
+	b.Write(e.Key[:])
+	b.Write(e.Trailer)
+	return nil
+}
+
+// Decode reads Everything from b, in the layout described by its jag tags.
+func (e *Everything) Decode(b *jagbuf.Buffer) error {
+	if err := e.Header.Decode(b); err != nil {
+		return err
+	}
+	{
+		v, err := b.ReadUint8()
+		if err != nil {
+			return err
+		}
+		e.U8 = v
+	}
+	{
+		v, err := b.ReadUint8_Neg()
+		if err != nil {
+			return err
+		}
+		e.U8Neg = v
+	}
+	{
+		v, err := b.ReadInt8_Mirror()
+		if err != nil {
+			return err
+		}
+		e.I8Mirror = v
+	}
+	{
+		v, err := b.ReadUint16()
+		if err != nil {
+			return err
+		}
+		e.U16 = v
+	}
+	{
+		v, err := b.ReadUint16_Sub()
+		if err != nil {
+			return err
+		}
+		e.U16Add = int(v)
+	}
+	{
+		v, err := b.ReadUint16LE()
+		if err != nil {
+			return err
+		}
+		e.U16LE = v
+	}
+	{
+		v, err := b.ReadUint16LE_Sub()
+		if err != nil {
+			return err
+		}
+		e.U16LEAdd = v
+	}
+	{
+		v, err := b.ReadInt16LE()
+		if err != nil {
+			return err
+		}
+		e.I16LE = v
+	}
+	{
+		v, err := b.ReadIntN(2, jagbuf.BigEndian, jagbuf.TransformNeg)
+		if err != nil {
+			return err
+		}
+		e.I16Neg = int16(v)
+	}
+	{
+		v, err := b.ReadUint24()
+		if err != nil {
+			return err
+		}
+		e.U24 = v
+	}
+	{
+		v, err := b.ReadInt24LE()
+		if err != nil {
+			return err
+		}
+		e.I24LE = v
+	}
+	{
+		v, err := b.ReadUint32()
+		if err != nil {
+			return err
+		}
+		e.U32 = v
+	}
+	{
+		v, err := b.ReadInt32V1()
+		if err != nil {
+			return err
+		}
+		e.I32V1 = v
+	}
+	{
+		v, err := b.ReadUint32V2()
+		if err != nil {
+			return err
+		}
+		e.U32V2 = v
+	}
+	{
+		v, err := b.ReadUintN(4, jagbuf.LittleEndian, jagbuf.TransformAdd)
+		if err != nil {
+			return err
+		}
+		e.U32LEAdd = uint32(v)
+	}
+	{
+		v, err := b.ReadInt64()
+		if err != nil {
+			return err
+		}
+		e.I64 = v
+	}
+	{
+		v, err := b.ReadUint64LE()
+		if err != nil {
+			return err
+		}
+		e.U64LE = v
+	}
+	{
+		v, err := b.ReadSmart()
+		if err != nil {
+			return err
+		}
+		e.Smart = int(v)
+	}
+	{
+		v, err := b.ReadSignedSmart()
+		if err != nil {
+			return err
+		}
+		e.SSmart = v
+	}
+	{
+		v, err := b.ReadString()
+		if err != nil {
+			return err
+		}
+		e.Name = Name(v)
+	}
+	{
+		v, err := b.ReadJagString()
+		if err != nil {
+			return err
+		}
+		e.Title = jagbuf.DecodeCP1252([]byte(v))
+	}
+	{
+		v, err := b.ReadString()
+		if err != nil {
+			return err
+		}
+		e.Greeting = jagbuf.DecodeCP1252([]byte(v))
+	}
+	{
+		n, err := b.ReadUint8()
+		if err != nil {
+			return err
+		}
+		if uint64(n) > uint64(b.ReadableBytes()) {
+			return io.EOF
+		}
+		e.Path = make([]Step, n)
+	}
+	for i := range e.Path {
+		if err := e.Path[i].Decode(b); err != nil {
+			return err
+		}
+	}
+	{
+		n, err := b.ReadSmart()
+		if err != nil {
+			return err
+		}
+		if uint64(n) > uint64(b.ReadableBytes()) {
+			return io.EOF
+		}
+		e.Ids = make([]uint16, n)
+	}
+	for i := range e.Ids {
+		v, err := b.ReadUint16LE()
+		if err != nil {
+			return err
+		}
+		e.Ids[i] = v
+	}
+	for i := range e.Grid {
+		for j := range e.Grid[i] {
+			v, err := b.ReadInt8()
+			if err != nil {
+				return err
+			}
+			e.Grid[i][j] = int(v)
+		}
+	}
+	for i := range e.Colours {
+		v, err := b.ReadInt24()
+		if err != nil {
+			return err
+		}
+		e.Colours[i] = v
+	}
+	{
+		n, err := b.ReadUint16()
+		if err != nil {
+			return err
+		}
+		if uint64(n) > uint64(b.ReadableBytes()) {
+			return io.EOF
+		}
+		e.Payload = make([]byte, n)
+	}
+	if err := b.ReadBytes(e.Payload); err != nil {
+		return err
+	}
+	if err := b.ReadBytes(e.Key[:]); err != nil {
+		return err
+	}
+	e.Trailer = make([]byte, b.ReadableBytes())
+	if err := b.ReadBytes(e.Trailer); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Encode writes Narrow to b, in the layout described by its jag tags.
+func (p *Narrow) Encode(b *jagbuf.Buffer) error {
+	b.WriteUint16(uint16(p.U8))
+	if p.I8 < 0 {
+		return fmt.Errorf("Narrow.I8: value %d out of range", p.I8)
+	}
+	b.WriteSmart(uint16(p.I8))
+	b.WriteInt16(int16(p.Opcode))
+	if p.U64 > 9223372036854775807 {
+		return fmt.Errorf("Narrow.U64: value %d out of range", p.U64)
+	}
+	b.WriteInt64(int64(p.U64))
+	if p.Int < 0 || int64(p.Int) > 4294967295 {
+		return fmt.Errorf("Narrow.Int: value %d out of range", p.Int)
+	}
+	b.WriteUint32(uint32(p.Int))
+	if (p.Delay < 0 && int64(p.Delay) < -2147483648) || (p.Delay > 0 && uint64(p.Delay) > 2147483647) {
+		return fmt.Errorf("Narrow.Delay: value %d out of range", p.Delay)
+	}
+	b.WriteInt32(int32(p.Delay))
+	return nil
+}
+
+// Decode reads Narrow from b, in the layout described by its jag tags.
+func (p *Narrow) Decode(b *jagbuf.Buffer) error {
+	{
+		v, err := b.ReadUint16()
+		if err != nil {
+			return err
+		}
+		p.U8 = uint8(v)
+		if uint16(p.U8) != v {
+			return fmt.Errorf("Narrow.U8: value %d overflows uint8", v)
+		}
+	}
+	{
+		v, err := b.ReadSmart()
+		if err != nil {
+			return err
+		}
+		p.I8 = int8(v)
+		if uint16(p.I8) != v || p.I8 < 0 {
+			return fmt.Errorf("Narrow.I8: value %d overflows int8", v)
+		}
+	}
+	{
+		v, err := b.ReadInt16()
+		if err != nil {
+			return err
+		}
+		p.Opcode = Opcode(v)
+		if int16(p.Opcode) != v || v < 0 {
+			return fmt.Errorf("Narrow.Opcode: value %d overflows Opcode", v)
+		}
+	}
+	{
+		v, err := b.ReadInt64()
+		if err != nil {
+			return err
+		}
+		p.U64 = uint64(v)
+		if int64(p.U64) != v || v < 0 {
+			return fmt.Errorf("Narrow.U64: value %d overflows uint64", v)
+		}
+	}
+	{
+		v, err := b.ReadUint32()
+		if err != nil {
+			return err
+		}
+		p.Int = int(v)
+		if uint32(p.Int) != v || p.Int < 0 {
+			return fmt.Errorf("Narrow.Int: value %d overflows int", v)
+		}
+	}
+	{
+		v, err := b.ReadInt32()
+		if err != nil {
+			return err
+		}
+		p.Delay = time.Duration(v)
+		if int32(p.Delay) != v || (p.Delay < 0) != (v < 0) {
+			return fmt.Errorf("Narrow.Delay: value %d overflows time.Duration", v)
+		}
+	}
+	return nil
+}
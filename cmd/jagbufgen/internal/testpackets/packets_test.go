@@ -0,0 +1,187 @@
+package testpackets
+
+import (
+	"bytes"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+func testEverything() Everything {
+	return Everything{
+		Header:   Header{Opcode: 200, Size: 1000},
+		U8:       255,
+		U8Neg:    1,
+		I8Mirror: -100,
+		U16:      65535,
+		U16Add:   0x1234,
+		U16LE:    0x5678,
+		U16LEAdd: 0x9ABC,
+		I16LE:    -2,
+		I16Neg:   -300,
+		U24:      0xFFFFFF,
+		I24LE:    -8388608,
+		U32:      0xDEADBEEF,
+		I32V1:    -123456789,
+		U32V2:    0x01020304,
+		U32LEAdd: 0xCAFEBABE,
+		I64:      -1,
+		U64LE:    0x0102030405060708,
+		Smart:    32767,
+		SSmart:   -16384,
+		Name:     "name",
+		Title:    "Sir €",
+		Greeting: "Œuvre ™",
+		Path:     []Step{{1, -1}, {-1, 1}, {0, 0}},
+		Ids:      []uint16{1, 2, 65535},
+		Grid:     [2][2]int{{1, -2}, {3, -4}},
+		Colours:  [3]int32{-1, 0, 8388607},
+		Payload:  []byte{1, 2, 3},
+		Key:      [4]byte{9, 8, 7, 6},
+		Trailer:  []byte{0xFF},
+	}
+}
+
+func TestEncode_MatchesMarshal(t *testing.T) {
+	packet := testEverything()
+
+	marshalled, err := jagbuf.Marshal(&packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := jagbuf.NewBuffer()
+	if err := packet.Encode(encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(marshalled.Bytes(), encoded.Bytes()) {
+		t.Errorf("Encode fail: expected %v, got %v", marshalled.Bytes(), encoded.Bytes())
+	}
+}
+
+func TestDecode_MatchesUnmarshal(t *testing.T) {
+	packet := testEverything()
+
+	encoded := jagbuf.NewBuffer()
+	if err := packet.Encode(encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	var unmarshalled Everything
+	if err := jagbuf.Unmarshal(jagbuf.Wrap(encoded.Bytes()), &unmarshalled); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded Everything
+	if err := decoded.Decode(jagbuf.Wrap(encoded.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(packet, decoded) {
+		t.Errorf("Decode fail: expected %+v, got %+v", packet, decoded)
+	}
+
+	if !reflect.DeepEqual(unmarshalled, decoded) {
+		t.Errorf("Decode fail: Unmarshal gave %+v, Decode gave %+v", unmarshalled, decoded)
+	}
+}
+
+func TestDecode_Truncated(t *testing.T) {
+	packet := testEverything()
+
+	encoded := jagbuf.NewBuffer()
+	if err := packet.Encode(encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	data := encoded.Bytes()
+	for _, n := range []int{0, 1, 10, 60, len(data) - len(packet.Trailer) - 1} {
+		var decoded Everything
+		if err := decoded.Decode(jagbuf.Wrap(data[:n])); err == nil {
+			t.Errorf("Decode fail: expected an error decoding %d of %d bytes", n, len(data))
+		}
+	}
+}
+
+func TestNarrow_InRange(t *testing.T) {
+	packet := Narrow{U8: 255, I8: 127, Opcode: 255, U64: 1 << 62, Int: math.MaxInt32, Delay: -time.Nanosecond}
+
+	marshalled, err := jagbuf.Marshal(&packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded := jagbuf.NewBuffer()
+	if err := packet.Encode(encoded); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(marshalled.Bytes(), encoded.Bytes()) {
+		t.Errorf("Encode fail: expected %v, got %v", marshalled.Bytes(), encoded.Bytes())
+	}
+
+	var decoded Narrow
+	if err := decoded.Decode(encoded); err != nil || decoded != packet {
+		t.Errorf("Decode fail: expected %+v, got %+v, %v", packet, decoded, err)
+	}
+}
+
+func TestNarrow_EncodeOutOfRange(t *testing.T) {
+	tests := map[string]Narrow{
+		"negative smart": {I8: -1},
+		"u64 for i64":    {U64: 1 << 63},
+		"negative u32":   {Int: -1},
+		"large i32":      {Delay: 1 << 31},
+		"small i32":      {Delay: -1<<31 - 1},
+		"large negative": {Delay: -1 << 62},
+	}
+
+	if math.MaxInt > math.MaxUint32 {
+		large := int64(math.MaxUint32) + 1
+		tests["large u32"] = Narrow{Int: int(large)}
+	}
+
+	for name, packet := range tests {
+		if _, err := jagbuf.Marshal(&packet); err == nil {
+			t.Errorf("Marshal fail: %s: expected an error", name)
+		}
+		if err := packet.Encode(jagbuf.NewBuffer()); err == nil {
+			t.Errorf("Encode fail: %s: expected an error", name)
+		}
+	}
+}
+
+func TestNarrow_DecodeOverflow(t *testing.T) {
+	narrow := func(u8, smart uint16, opcode int16, u64 int64) []byte {
+		b := jagbuf.NewBuffer()
+		b.WriteUint16(u8)
+		b.WriteSmart(smart)
+		b.WriteInt16(opcode)
+		b.WriteInt64(u64)
+		b.WriteUint32(0)
+		b.WriteInt32(0)
+		return b.Bytes()
+	}
+
+	tests := map[string][]byte{
+		"u16 into uint8":  narrow(0x1234, 0, 0, 0),
+		"smart into int8": narrow(0, 128, 0, 0),
+		"i16 into Opcode": narrow(0, 0, 256, 0),
+		"negative Opcode": narrow(0, 0, -1, 0),
+		"i64 into uint64": narrow(0, 0, 0, -1),
+	}
+
+	for name, data := range tests {
+		if err := jagbuf.Unmarshal(jagbuf.Wrap(data), &Narrow{}); err == nil {
+			t.Errorf("Unmarshal fail: %s: expected an error", name)
+		}
+
+		var decoded Narrow
+		if err := decoded.Decode(jagbuf.Wrap(data)); err == nil {
+			t.Errorf("Decode fail: %s: expected an error, got %+v", name, decoded)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+// Jagbufgen generates reflection free Encode and Decode methods for structs
+// described with jag tags, producing the same layout as jagbuf.Marshal and
+// jagbuf.Unmarshal without the cost of reflection.
+//
+// Usage:
+//
+//	jagbufgen [-type T,U] [-output file] file.go
+//
+// Without -type, methods are generated for every struct in file.go that has
+// at least one jag tag. The output defaults to file_jag.go. It is typically
+// run with go generate:
+//
+//	//go:generate go run github.com/apogee-rs/jagbuf/cmd/jagbufgen packets.go
+//
+// The generated methods are
+//
+//	func (p *T) Encode(b *jagbuf.Buffer) error
+//	func (p *T) Decode(b *jagbuf.Buffer) error
+//
+// and call the dedicated Buffer methods, such as WriteUint16LE or
+// ReadInt32V2, wherever the package has one for the tagged layout. Struct
+// fields are encoded by calling their own Encode and Decode methods, so
+// nested structs need methods generated as well.
+//
+// Like Marshal and Unmarshal, Encode fails if a value does not fit its wire
+// type and Decode if it does not fit its field. The checks are left out
+// where the type of the field rules them out, for predeclared integer types
+// and types declared in file.go as one. Encode may have written part of the
+// struct to b when it fails.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma separated list of struct names; default all tagged structs")
+	output := flag.String("output", "", "output file name; default <file>_jag.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: jagbufgen [-type T,U] [-output file] file.go\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if err := run(*typeNames, *output, flag.Args()); err != nil {
+		fmt.Fprintf(os.Stderr, "jagbufgen: %v\n", err)
+		if errors.Is(err, errUsage) {
+			flag.Usage()
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// errUsage is returned by run when it is not given a single input file.
+var errUsage = errors.New("expected a single input file")
+
+func run(typeNames string, output string, args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	input := args[0]
+
+	var names []string
+	if typeNames != "" {
+		names = strings.Split(typeNames, ",")
+	}
+
+	src, err := os.ReadFile(input)
+	if err != nil {
+		return err
+	}
+
+	parsed, err := parseFile(input, src, names)
+	if err != nil {
+		return err
+	}
+
+	generated, err := generate(parsed, os.Args[1:])
+	if err != nil {
+		return err
+	}
+
+	if output == "" {
+		output = strings.TrimSuffix(input, ".go") + "_jag.go"
+	}
+
+	return os.WriteFile(output, generated, 0o644)
+}
@@ -0,0 +1,212 @@
+// Package container encodes and decodes JS5 containers, the unit in which
+// every group of a JS5 cache is stored and served by the update server.
+//
+// A container is laid out as
+//
+//	u8   compression type
+//	u32  length of the payload as stored
+//	u32  length of the uncompressed data, only when compressed
+//	     payload
+//	u16  version, optional
+//
+// When the container is encrypted, everything after the first 5 bytes up to
+// the version is enciphered with XTEA.
+package container
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/apogee-rs/jagbuf"
+	"github.com/apogee-rs/jagbuf/bzip2"
+	"github.com/apogee-rs/jagbuf/lzma"
+)
+
+// Compression is the compression type of a container.
+type Compression uint8
+
+const (
+	None Compression = iota
+	Bzip2
+	Gzip
+	LZMA
+)
+
+func (c Compression) String() string {
+	switch c {
+	case None:
+		return "none"
+	case Bzip2:
+		return "bzip2"
+	case Gzip:
+		return "gzip"
+	case LZMA:
+		return "lzma"
+	}
+	return fmt.Sprintf("Compression(%d)", uint8(c))
+}
+
+// Container is a decoded JS5 container.
+type Container struct {
+	Compression Compression
+	// Data is the uncompressed, decrypted payload.
+	Data []byte
+
+	// HasVersion reports whether the container ends with a version, which
+	// is the case for containers stored in the cache but not for those
+	// served by the update server.
+	HasVersion bool
+	Version    uint16
+}
+
+// Decode reads a container from b, decrypting it with key and decompressing
+// it. Every readable byte of b is taken as part of the container, with 2
+// bytes left after the payload read as the version.
+func Decode(b *jagbuf.Buffer, key Key) (*Container, error) {
+	compression, err := b.ReadUint8()
+	if err != nil {
+		return nil, fmt.Errorf("container: %w", err)
+	}
+
+	length, err := b.ReadUint32()
+	if err != nil {
+		return nil, fmt.Errorf("container: %w", err)
+	}
+
+	c := &Container{Compression: Compression(compression)}
+
+	// The uncompressed length is encrypted along with the payload, so both
+	// are read out and decrypted together.
+	encrypted := uint64(length)
+	if c.Compression != None {
+		encrypted += 4
+	}
+	if encrypted > uint64(b.ReadableBytes()) {
+		return nil, fmt.Errorf("container: payload of %d bytes: %w", length, io.ErrUnexpectedEOF)
+	}
+
+	payload := make([]byte, encrypted)
+	if err := b.ReadBytes(payload); err != nil {
+		return nil, fmt.Errorf("container: %w", err)
+	}
+
+	if !key.IsZero() {
+		key.Decrypt(payload)
+	}
+
+	if b.ReadableBytes() >= 2 {
+		c.HasVersion = true
+		c.Version, _ = b.ReadUint16()
+	}
+
+	if c.Compression == None {
+		c.Data = payload
+		return c, nil
+	}
+
+	uncompressed, _ := jagbuf.Wrap(payload).ReadUint32()
+
+	if c.Data, err = decompress(c.Compression, payload[4:], uncompressed); err != nil {
+		return nil, fmt.Errorf("container: %v: %w", c.Compression, err)
+	}
+
+	return c, nil
+}
+
+// Encode compresses the container, encrypts it with key and writes it to b.
+func (c *Container) Encode(b *jagbuf.Buffer, key Key) error {
+	payload, err := compress(c.Compression, c.Data)
+	if err != nil {
+		return fmt.Errorf("container: %v: %w", c.Compression, err)
+	}
+
+	if uint64(len(payload)) > math.MaxUint32 || uint64(len(c.Data)) > math.MaxUint32 {
+		return fmt.Errorf("container: %d bytes too long", len(c.Data))
+	}
+
+	data := make([]byte, 0, len(payload)+4)
+	if c.Compression != None {
+		data = binary.BigEndian.AppendUint32(data, uint32(len(c.Data)))
+	}
+	data = append(data, payload...)
+
+	if !key.IsZero() {
+		key.Encrypt(data)
+	}
+
+	b.WriteUint8(uint8(c.Compression))
+	b.WriteUint32(uint32(len(payload)))
+	b.Write(data)
+
+	if c.HasVersion {
+		b.WriteUint16(c.Version)
+	}
+
+	return nil
+}
+
+// errLength is returned when the decompressed data does not match the
+// uncompressed length of the container.
+var errLength = errors.New("uncompressed length mismatch")
+
+func decompress(compression Compression, payload []byte, length uint32) ([]byte, error) {
+	var r io.Reader
+	switch compression {
+	case Bzip2:
+		r = bzip2.NewReader(bytes.NewReader(payload))
+	case Gzip:
+		gz, err := gzip.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, err
+		}
+		r = gz
+	case LZMA:
+		return lzma.Decompress(jagbuf.Wrap(payload), int(length))
+	default:
+		return nil, errors.ErrUnsupported
+	}
+
+	// Grow the output as data is decompressed rather than trusting the
+	// length, so a bogus length cannot cause a large allocation.
+	data, err := io.ReadAll(io.LimitReader(r, int64(length)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != int(length) {
+		return nil, errLength
+	}
+
+	return data, nil
+}
+
+func compress(compression Compression, data []byte) ([]byte, error) {
+	switch compression {
+	case None:
+		return data, nil
+	case Bzip2:
+		out := jagbuf.NewBuffer()
+		bzip2.Compress(out, data)
+		return out.Bytes(), nil
+	case Gzip:
+		out := &bytes.Buffer{}
+		gz := gzip.NewWriter(out)
+		if _, err := gz.Write(data); err != nil {
+			return nil, err
+		}
+		if err := gz.Close(); err != nil {
+			return nil, err
+		}
+		return out.Bytes(), nil
+	case LZMA:
+		out := jagbuf.NewBuffer()
+		lzma.Compress(out, data)
+		return out.Bytes(), nil
+	default:
+		return nil, errors.ErrUnsupported
+	}
+}
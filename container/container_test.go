@@ -0,0 +1,148 @@
+package container
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+var testKey = Key{0x00010203, 0x04050607, 0x08090A0B, 0x0C0D0E0F}
+
+func TestKey_EncryptDecrypt(t *testing.T) {
+	// Reference XTEA test vector, with 3 trailing bytes that do not fill a
+	// block and are left as they are.
+	data := []byte{0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 1, 2, 3}
+	expected, _ := hex.DecodeString("497df3d072612cb5010203")
+
+	testKey.Encrypt(data)
+	if !bytes.Equal(data, expected) {
+		t.Fatalf("Encrypt fail: expected %x, got %x", expected, data)
+	}
+
+	testKey.Decrypt(data)
+	if string(data) != "ABCDEFGH\x01\x02\x03" {
+		t.Errorf("Decrypt fail: expected the plaintext back, got %x", data)
+	}
+}
+
+func TestContainer_RoundTrip(t *testing.T) {
+	data := bytes.Repeat([]byte("container data "), 64)
+
+	for _, compression := range []Compression{None, Bzip2, Gzip, LZMA} {
+		for _, key := range []Key{ZeroKey, testKey} {
+			for _, hasVersion := range []bool{false, true} {
+				c := &Container{Compression: compression, Data: data, HasVersion: hasVersion, Version: 1234}
+
+				buffer := jagbuf.NewBuffer()
+				if err := c.Encode(buffer, key); err != nil {
+					t.Fatal(err)
+				}
+
+				decoded, err := Decode(buffer, key)
+				if err != nil {
+					t.Fatalf("Decode fail for %v: %v", compression, err)
+				}
+
+				if !bytes.Equal(decoded.Data, data) || decoded.Compression != compression {
+					t.Errorf("Decode fail: %v container did not round trip", compression)
+				}
+				if decoded.HasVersion != hasVersion || (hasVersion && decoded.Version != 1234) {
+					t.Errorf("Decode fail: expected version %t, got %t (%d)", hasVersion, decoded.HasVersion, decoded.Version)
+				}
+			}
+		}
+	}
+}
+
+func TestContainer_EncodeLayout(t *testing.T) {
+	c := &Container{Compression: None, Data: []byte{1, 2, 3}, HasVersion: true, Version: 7}
+
+	buffer := jagbuf.NewBuffer()
+	if err := c.Encode(buffer, ZeroKey); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0, 0, 0, 0, 3, 1, 2, 3, 0, 7}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("Encode fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+}
+
+func TestDecode_Bzip2(t *testing.T) {
+	// The output of bzip2 -1 for "hello hello hello jagex", with the BZh1
+	// header stripped like the client does.
+	stream, _ := hex.DecodeString("314159265359eabcabe80000049180400022d480402000223d40d3420c988e1216b470e09f177245385090eabcabe8")
+
+	buffer := jagbuf.NewBuffer()
+	buffer.WriteUint8(uint8(Bzip2))
+	buffer.WriteUint32(uint32(len(stream)))
+	buffer.WriteUint32(23)
+	buffer.Write(stream)
+
+	c, err := Decode(buffer, ZeroKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(c.Data) != "hello hello hello jagex" || c.HasVersion {
+		t.Errorf("Decode fail: expected \"hello hello hello jagex\", got %q", c.Data)
+	}
+}
+
+func TestDecode_LZMA(t *testing.T) {
+	// The output of xz --format=lzma for "hello hello hello jagex", with
+	// the uncompressed size stripped from the header like the client does.
+	stream, _ := hex.DecodeString("5d0000800000341949ee8de95095f9cc940e35b45065ffffee880000")
+
+	buffer := jagbuf.NewBuffer()
+	buffer.WriteUint8(uint8(LZMA))
+	buffer.WriteUint32(uint32(len(stream)))
+	buffer.WriteUint32(23)
+	buffer.Write(stream)
+	buffer.WriteUint16(7)
+
+	c, err := Decode(buffer, ZeroKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(c.Data) != "hello hello hello jagex" || c.Version != 7 {
+		t.Errorf("Decode fail: expected \"hello hello hello jagex\", got %q", c.Data)
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	gzipped := jagbuf.NewBuffer()
+	_ = (&Container{Compression: Gzip, Data: []byte("data")}).Encode(gzipped, ZeroKey)
+	wrongLength := gzipped.Bytes()
+	wrongLength[8] = 5
+
+	tests := map[string][]byte{
+		"empty":       {},
+		"short":       {0, 0, 0, 0, 5, 1, 2},
+		"huge length": {1, 0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0},
+		"wrong size":  wrongLength,
+		"unknown":     {9, 0, 0, 0, 0, 0, 0, 0, 0},
+	}
+
+	for name, data := range tests {
+		if _, err := Decode(jagbuf.Wrap(data), ZeroKey); err == nil {
+			t.Errorf("Decode fail: expected an error for %s", name)
+		}
+	}
+
+	if _, err := Decode(jagbuf.Wrap(tests["short"]), ZeroKey); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Decode fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
+
+func TestEncode_Unsupported(t *testing.T) {
+	for _, compression := range []Compression{LZMA + 1, 0xFF} {
+		err := (&Container{Compression: compression}).Encode(jagbuf.NewBuffer(), ZeroKey)
+		if !errors.Is(err, errors.ErrUnsupported) {
+			t.Errorf("Encode fail: expected ErrUnsupported for %v, got %v", compression, err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package container
+
+import "encoding/binary"
+
+const (
+	xteaRounds = 32
+	xteaDelta  = 0x9E3779B9
+	// xteaSum is xteaDelta * xteaRounds, truncated to 32 bits.
+	xteaSum = 0xC6EF3720
+)
+
+// Key is an XTEA key, as used to encrypt map groups in the cache. The zero
+// Key means the data is not encrypted.
+type Key [4]uint32
+
+// ZeroKey is the key of unencrypted data.
+var ZeroKey Key
+
+// IsZero reports whether k is the zero Key.
+func (k Key) IsZero() bool {
+	return k == ZeroKey
+}
+
+// Encrypt enciphers data in place in 8 byte blocks, leaving any trailing
+// bytes that do not fill a block unencrypted like the client does.
+func (k Key) Encrypt(data []byte) {
+	for i := 0; i+8 <= len(data); i += 8 {
+		v0 := binary.BigEndian.Uint32(data[i : i+4])
+		v1 := binary.BigEndian.Uint32(data[i+4 : i+8])
+
+		var sum uint32
+		for range xteaRounds {
+			v0 += (v1<<4 ^ v1>>5 + v1) ^ (sum + k[sum&3])
+			sum += xteaDelta
+			v1 += (v0<<4 ^ v0>>5 + v0) ^ (sum + k[sum>>11&3])
+		}
+
+		binary.BigEndian.PutUint32(data[i:i+4], v0)
+		binary.BigEndian.PutUint32(data[i+4:i+8], v1)
+	}
+}
+
+// Decrypt deciphers data encrypted by Encrypt in place.
+func (k Key) Decrypt(data []byte) {
+	for i := 0; i+8 <= len(data); i += 8 {
+		v0 := binary.BigEndian.Uint32(data[i : i+4])
+		v1 := binary.BigEndian.Uint32(data[i+4 : i+8])
+
+		sum := uint32(xteaSum)
+		for range xteaRounds {
+			v1 -= (v0<<4 ^ v0>>5 + v0) ^ (sum + k[sum>>11&3])
+			sum -= xteaDelta
+			v0 -= (v1<<4 ^ v1>>5 + v1) ^ (sum + k[sum&3])
+		}
+
+		binary.BigEndian.PutUint32(data[i:i+4], v0)
+		binary.BigEndian.PutUint32(data[i+4:i+8], v1)
+	}
+}
@@ -0,0 +1,165 @@
+// Package tag parses the `jag` struct tags shared by jagbuf.Marshal and the
+// jagbufgen code generator, so both read the same layout from a tag.
+package tag
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Kind is the wire type of a field.
+type Kind int
+
+const (
+	Int Kind = iota
+	Smart
+	String
+	Bytes
+	// Struct is reported for tags without a type, which are only valid on
+	// struct fields.
+	Struct
+)
+
+// Order mirrors jagbuf.Order, and has the same values.
+type Order int
+
+const (
+	BigEndian Order = iota
+	LittleEndian
+	MiddleEndianV1
+	MiddleEndianV2
+)
+
+// Transform mirrors jagbuf.Transform, and has the same values.
+type Transform int
+
+const (
+	None Transform = iota
+	Add
+	Neg
+	Mirror
+)
+
+// Spec is a parsed `jag` tag.
+type Spec struct {
+	Kind      Kind
+	Width     int
+	Signed    bool
+	Order     Order
+	Transform Transform
+	Jag       bool
+	CP1252    bool
+	// Length is the count prefix of a slice, if any.
+	Length *Spec
+}
+
+var types = map[string]Spec{
+	"u8":        {Kind: Int, Width: 1},
+	"i8":        {Kind: Int, Width: 1, Signed: true},
+	"u16":       {Kind: Int, Width: 2},
+	"i16":       {Kind: Int, Width: 2, Signed: true},
+	"u24":       {Kind: Int, Width: 3},
+	"i24":       {Kind: Int, Width: 3, Signed: true},
+	"u32":       {Kind: Int, Width: 4},
+	"i32":       {Kind: Int, Width: 4, Signed: true},
+	"u64":       {Kind: Int, Width: 8},
+	"i64":       {Kind: Int, Width: 8, Signed: true},
+	"smart":     {Kind: Smart},
+	"ssmart":    {Kind: Smart, Signed: true},
+	"string":    {Kind: String},
+	"jagstring": {Kind: String, Jag: true},
+	"bytes":     {Kind: Bytes},
+}
+
+var orders = map[string]Order{
+	"be": BigEndian,
+	"le": LittleEndian,
+	"v1": MiddleEndianV1,
+	"v2": MiddleEndianV2,
+}
+
+var transforms = map[string]Transform{
+	"add":    Add,
+	"neg":    Neg,
+	"mirror": Mirror,
+}
+
+// Parse parses a `jag` tag, a wire type followed by comma separated
+// options.
+func Parse(tag string) (*Spec, error) {
+	parts := strings.Split(tag, ",")
+
+	spec := &Spec{Kind: Struct}
+	if name := strings.TrimSpace(parts[0]); !strings.Contains(name, "=") {
+		if name != "" {
+			typ, ok := types[name]
+			if !ok {
+				return nil, fmt.Errorf("unknown type %q", name)
+			}
+			*spec = typ
+		}
+		parts = parts[1:]
+	}
+
+	for _, opt := range parts {
+		opt = strings.TrimSpace(opt)
+		order, isOrder := orders[opt]
+		transform, isTransform := transforms[opt]
+
+		switch {
+		case isOrder:
+			if spec.Kind != Int {
+				return nil, fmt.Errorf("option %q is only valid for integers", opt)
+			}
+			spec.Order = order
+			if spec.Order >= MiddleEndianV1 && spec.Width != 4 {
+				return nil, fmt.Errorf("option %q is only valid for 32-bit integers", opt)
+			}
+		case isTransform:
+			if spec.Kind != Int {
+				return nil, fmt.Errorf("option %q is only valid for integers", opt)
+			}
+			spec.Transform = transform
+		case opt == "cp1252":
+			if spec.Kind != String {
+				return nil, fmt.Errorf("option %q is only valid for strings", opt)
+			}
+			spec.CP1252 = true
+		case strings.HasPrefix(opt, "len="):
+			length, err := Parse(strings.TrimPrefix(opt, "len="))
+			if err != nil {
+				return nil, fmt.Errorf("len: %w", err)
+			}
+			if length.Kind != Int && length.Kind != Smart {
+				return nil, fmt.Errorf("len must be an integer type, got %q", opt)
+			}
+			spec.Length = length
+		default:
+			return nil, fmt.Errorf("unknown option %q", opt)
+		}
+	}
+
+	return spec, nil
+}
+
+// Element returns the spec of a single element of a counted slice.
+func (s *Spec) Element() *Spec {
+	elem := *s
+	elem.Length = nil
+	return &elem
+}
+
+// Range returns the range of values an Int or Smart spec can hold.
+func (s *Spec) Range() (int64, uint64) {
+	switch {
+	case s.Kind == Smart && s.Signed:
+		return -0x4000, 0x3FFF
+	case s.Kind == Smart:
+		return 0, 0x7FFF
+	case s.Signed:
+		return math.MinInt64 >> (64 - 8*s.Width), math.MaxInt64 >> (64 - 8*s.Width)
+	default:
+		return 0, math.MaxUint64 >> (64 - 8*s.Width)
+	}
+}
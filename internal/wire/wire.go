@@ -0,0 +1,105 @@
+// Package wire reads and writes the integers and count prefixes described by
+// a parsed `jag` tag, shared by jagbuf.Marshal and the schema package.
+//
+// The functions are generic over the buffer and the jagbuf Order and
+// Transform types, as this package cannot import jagbuf, which imports it.
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apogee-rs/jagbuf/internal/tag"
+)
+
+// Reader is the part of *jagbuf.Buffer used to read integers.
+type Reader[O, T any] interface {
+	ReadSmart() (uint16, error)
+	ReadSignedSmart() (int16, error)
+	ReadUintN(n int, o O, t T) (uint64, error)
+	ReadIntN(n int, o O, t T) (int64, error)
+	ReadableBytes() int
+}
+
+// Writer is the part of *jagbuf.Buffer used to write integers.
+type Writer[O, T any] interface {
+	WriteSmart(v uint16)
+	WriteSignedSmart(v int16)
+	WriteUintN(n int, v uint64, o O, t T)
+}
+
+// ReadInt reads an integer laid out as spec, returning its bits. Signed
+// values are sign extended.
+func ReadInt[O, T ~int](r Reader[O, T], spec *tag.Spec) (uint64, error) {
+	switch {
+	case spec.Kind == tag.Smart && spec.Signed:
+		val, err := r.ReadSignedSmart()
+		return uint64(val), err
+	case spec.Kind == tag.Smart:
+		val, err := r.ReadSmart()
+		return uint64(val), err
+	case spec.Signed:
+		val, err := r.ReadIntN(spec.Width, O(spec.Order), T(spec.Transform))
+		return uint64(val), err
+	default:
+		return r.ReadUintN(spec.Width, O(spec.Order), T(spec.Transform))
+	}
+}
+
+// WriteInt writes the bits of an integer laid out as spec. The value must
+// already have been checked with IntBits or UintBits.
+func WriteInt[O, T ~int](w Writer[O, T], spec *tag.Spec, v uint64) {
+	switch {
+	case spec.Kind == tag.Smart && spec.Signed:
+		w.WriteSignedSmart(int16(v))
+	case spec.Kind == tag.Smart:
+		w.WriteSmart(uint16(v))
+	default:
+		w.WriteUintN(spec.Width, v, O(spec.Order), T(spec.Transform))
+	}
+}
+
+// ReadCount reads a count prefix. A count larger than the remaining data is
+// rejected with io.EOF before anything is allocated for it, as every element
+// takes at least a byte.
+func ReadCount[O, T ~int](r Reader[O, T], spec *tag.Spec) (int, error) {
+	bits, err := ReadInt(r, spec)
+	if err != nil {
+		return 0, err
+	}
+
+	count := int64(bits)
+	if count < 0 || count > int64(r.ReadableBytes()) {
+		return 0, io.EOF
+	}
+
+	return int(count), nil
+}
+
+// WriteCount writes a count prefix, failing if count does not fit spec.
+func WriteCount[O, T ~int](w Writer[O, T], spec *tag.Spec, count int) error {
+	if _, hi := spec.Range(); uint64(count) > hi {
+		return fmt.Errorf("length %d out of range", count)
+	}
+
+	WriteInt(w, spec, uint64(count))
+	return nil
+}
+
+// IntBits returns the bits of i to write for spec, failing if it is out of
+// range.
+func IntBits(spec *tag.Spec, i int64) (uint64, error) {
+	if lo, hi := spec.Range(); i < lo || (i > 0 && uint64(i) > hi) {
+		return 0, fmt.Errorf("value %d out of range", i)
+	}
+	return uint64(i), nil
+}
+
+// UintBits returns the bits of u to write for spec, failing if it is out of
+// range.
+func UintBits(spec *tag.Spec, u uint64) (uint64, error) {
+	if _, hi := spec.Range(); u > hi {
+		return 0, fmt.Errorf("value %d out of range", u)
+	}
+	return u, nil
+}
@@ -0,0 +1,249 @@
+// Package jag reads and writes the .jag archives of older revisions, such
+// as the title, config and media archives of the 317 cache.
+//
+// An archive is laid out as
+//
+//	u24  decompressed length of the body
+//	u24  compressed length of the body
+//	     body
+//
+// with the body compressed with bzip2 as a whole when the lengths differ.
+// The body is laid out as
+//
+//	u16  file count
+//	     for each file: i32 name hash, u24 decompressed length, u24 stored length
+//	     the data of each file in turn
+//
+// with each file compressed on its own when its lengths differ, unless the
+// body is compressed as a whole. The bzip2 streams have their header
+// stripped, as in JS5 containers.
+package jag
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/apogee-rs/jagbuf"
+	"github.com/apogee-rs/jagbuf/bzip2"
+)
+
+const (
+	// MaxFiles is the most files an archive can hold.
+	MaxFiles = 0xFFFF
+	// MaxLen is the longest a file or body can be.
+	MaxLen = 0xFFFFFF
+
+	// directoryLen is the length of the directory entry of each file.
+	directoryLen = 10
+)
+
+// Archive is a decoded .jag archive.
+type Archive struct {
+	// Compressed reports whether the body of the archive is compressed as
+	// a whole, rather than each file on its own.
+	Compressed bool
+
+	Files []File
+}
+
+// File is a file in an archive, identified only by the hash of its name.
+type File struct {
+	NameHash int32
+	Data     []byte
+}
+
+// File returns the file with the given name, or nil if there is none. Names
+// are case insensitive.
+func (a *Archive) File(name string) *File {
+	return a.FileByNameHash(jagbuf.JagNameHash(name))
+}
+
+// FileByNameHash returns the first file with the given name hash, or nil if
+// there is none.
+func (a *Archive) FileByNameHash(hash int32) *File {
+	for i := range a.Files {
+		if a.Files[i].NameHash == hash {
+			return &a.Files[i]
+		}
+	}
+	return nil
+}
+
+// Put replaces the data of the file with the given name, adding the file if
+// there is none.
+func (a *Archive) Put(name string, data []byte) {
+	if f := a.File(name); f != nil {
+		f.Data = data
+		return
+	}
+	a.Files = append(a.Files, File{NameHash: jagbuf.JagNameHash(name), Data: data})
+}
+
+// Decode reads an archive from b.
+func Decode(b *jagbuf.Buffer) (*Archive, error) {
+	d := jagbuf.NewDecoder(b)
+	length := d.ReadUint24()
+	stored := d.ReadUint24()
+	if err := d.Err(); err != nil {
+		return nil, fmt.Errorf("jag: %w", err)
+	}
+
+	body, err := readData(b, length, stored)
+	if err != nil {
+		return nil, fmt.Errorf("jag: body: %w", err)
+	}
+
+	a := &Archive{Compressed: length != stored}
+
+	b = jagbuf.Wrap(body)
+	d = jagbuf.NewDecoder(b)
+
+	count := int(d.ReadUint16())
+	if d.Err() == nil && count*directoryLen > b.ReadableBytes() {
+		return nil, fmt.Errorf("jag: directory of %d files: %w", count, io.ErrUnexpectedEOF)
+	}
+
+	type entry struct {
+		length uint32
+		stored uint32
+	}
+	entries := make([]entry, count)
+	if count > 0 {
+		a.Files = make([]File, count)
+	}
+
+	for i := range a.Files {
+		a.Files[i].NameHash = d.ReadInt32()
+		entries[i].length = d.ReadUint24()
+		entries[i].stored = d.ReadUint24()
+	}
+	if err := d.Err(); err != nil {
+		return nil, fmt.Errorf("jag: %w", err)
+	}
+
+	for i, e := range entries {
+		// Like the client, the files of a compressed archive are read as
+		// they are, even if their lengths differ.
+		length := e.length
+		if a.Compressed {
+			length = e.stored
+		}
+
+		if a.Files[i].Data, err = readData(b, length, e.stored); err != nil {
+			return nil, fmt.Errorf("jag: file %d: %w", i, err)
+		}
+	}
+
+	return a, nil
+}
+
+// errLength is returned when data does not decompress to its length.
+var errLength = errors.New("decompressed length mismatch")
+
+// errCompressedLength is returned when a compressed body is as long as the
+// body, as it would then be read as uncompressed.
+var errCompressedLength = errors.New("compressed body as long as the body")
+
+// readData reads stored bytes from b, decompressing them if they are not
+// length bytes long.
+func readData(b *jagbuf.Buffer, length, stored uint32) ([]byte, error) {
+	if int(stored) > b.ReadableBytes() {
+		return nil, fmt.Errorf("%d bytes: %w", stored, io.ErrUnexpectedEOF)
+	}
+
+	data := make([]byte, stored)
+	_ = b.ReadBytes(data)
+	if length == stored {
+		return data, nil
+	}
+
+	// Grow the output as data is decompressed rather than trusting the
+	// length, so a bogus length cannot cause a large allocation.
+	r := bzip2.NewReader(bytes.NewReader(data))
+	data, err := io.ReadAll(io.LimitReader(r, int64(length)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) != int(length) {
+		return nil, errLength
+	}
+
+	return data, nil
+}
+
+// Encode writes the archive to b, compressing the body as a whole if
+// Compressed is set and each file on its own otherwise. The body is always
+// compressed when Compressed is set, so the flag survives a round trip, and
+// Encode fails in the rare case that makes it exactly as long as it was, as
+// the client would then read it as uncompressed. Files are stored
+// uncompressed where compressing them would not make them any smaller, as
+// the client only decompresses them when their lengths differ.
+func (a *Archive) Encode(b *jagbuf.Buffer) error {
+	if len(a.Files) > MaxFiles {
+		return fmt.Errorf("jag: %d files out of range", len(a.Files))
+	}
+	for i, f := range a.Files {
+		if len(f.Data) > MaxLen {
+			return fmt.Errorf("jag: file %d of %d bytes too long", i, len(f.Data))
+		}
+	}
+
+	stored := make([][]byte, len(a.Files))
+	for i, f := range a.Files {
+		if a.Compressed {
+			stored[i] = f.Data
+		} else {
+			stored[i] = compress(f.Data)
+		}
+	}
+
+	body := jagbuf.NewBuffer()
+	body.WriteUint16(uint16(len(a.Files)))
+	for i, f := range a.Files {
+		body.WriteInt32(f.NameHash)
+		body.WriteUint24(uint32(len(f.Data)))
+		body.WriteUint24(uint32(len(stored[i])))
+	}
+	for _, data := range stored {
+		body.Write(data)
+	}
+
+	data := body.Bytes()
+	if len(data) > MaxLen {
+		return fmt.Errorf("jag: body of %d bytes too long", len(data))
+	}
+
+	out := data
+	if a.Compressed {
+		compressed := jagbuf.NewBuffer()
+		bzip2.Compress(compressed, data)
+		out = compressed.Bytes()
+
+		switch {
+		case len(out) == len(data):
+			return fmt.Errorf("jag: %w", errCompressedLength)
+		case len(out) > MaxLen:
+			return fmt.Errorf("jag: compressed body of %d bytes too long", len(out))
+		}
+	}
+
+	b.WriteUint24(uint32(len(data)))
+	b.WriteUint24(uint32(len(out)))
+	b.Write(out)
+
+	return nil
+}
+
+// compress returns data compressed with bzip2, or data itself if that is no
+// smaller.
+func compress(data []byte) []byte {
+	b := jagbuf.NewBuffer()
+	bzip2.Compress(b, data)
+
+	if b.ReadableBytes() >= len(data) {
+		return data
+	}
+	return b.Bytes()
+}
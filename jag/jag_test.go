@@ -0,0 +1,215 @@
+package jag
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/apogee-rs/jagbuf"
+	"github.com/apogee-rs/jagbuf/bzip2"
+)
+
+func testArchive(compressed bool) *Archive {
+	random := make([]byte, 1000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	a := &Archive{Compressed: compressed}
+	a.Put("logo.dat", bytes.Repeat([]byte("logo "), 200))
+	a.Put("index.dat", []byte{1, 2, 3})
+	a.Put("random.dat", random)
+	a.Put("empty.dat", nil)
+	return a
+}
+
+func TestArchive_RoundTrip(t *testing.T) {
+	for _, compressed := range []bool{false, true} {
+		a := testArchive(compressed)
+
+		buffer := jagbuf.NewBuffer()
+		if err := a.Encode(buffer); err != nil {
+			t.Fatal(err)
+		}
+
+		decoded, err := Decode(buffer)
+		if err != nil {
+			t.Fatalf("Decode fail: compressed %t: %v", compressed, err)
+		}
+
+		if decoded.Compressed != compressed || len(decoded.Files) != len(a.Files) {
+			t.Fatalf("Decode fail: expected %d files, compressed %t, got %d, %t", len(a.Files), compressed, len(decoded.Files), decoded.Compressed)
+		}
+		for i, f := range a.Files {
+			if decoded.Files[i].NameHash != f.NameHash || !bytes.Equal(decoded.Files[i].Data, f.Data) {
+				t.Errorf("Decode fail: compressed %t: file %d did not round trip", compressed, i)
+			}
+		}
+	}
+}
+
+func TestArchive_PerFileCompression(t *testing.T) {
+	buffer := jagbuf.NewBuffer()
+	if err := testArchive(false).Encode(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	d := jagbuf.NewDecoder(buffer)
+	length, stored := d.ReadUint24(), d.ReadUint24()
+	if length != stored {
+		t.Fatalf("Encode fail: expected an uncompressed body, got lengths %d and %d", length, stored)
+	}
+
+	d.ReadUint16()
+	var lengths [][2]uint32
+	for range 4 {
+		d.ReadInt32()
+		lengths = append(lengths, [2]uint32{d.ReadUint24(), d.ReadUint24()})
+	}
+
+	// Only the file that compresses well is stored compressed.
+	expected := [][2]uint32{{1000, lengths[0][1]}, {3, 3}, {1000, 1000}, {0, 0}}
+	if !reflect.DeepEqual(lengths, expected) || lengths[0][1] >= 1000 {
+		t.Errorf("Encode fail: expected lengths %v, got %v", expected, lengths)
+	}
+}
+
+func TestArchive_CompressedTiny(t *testing.T) {
+	// Compressing a tiny body makes it larger, but it is still compressed
+	// so the archive decodes as compressed.
+	a := &Archive{Compressed: true}
+	a.Put("a", []byte("xy"))
+
+	buffer := jagbuf.NewBuffer()
+	if err := a.Encode(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	decoded, err := Decode(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Compressed || !bytes.Equal(decoded.File("a").Data, []byte("xy")) {
+		t.Errorf("Decode fail: expected a compressed archive holding \"xy\", got %t, %v", decoded.Compressed, decoded.Files)
+	}
+}
+
+func TestArchive_CompressedLength(t *testing.T) {
+	random := make([]byte, 400)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	// Some mix of random data and zeros compresses to exactly the length of
+	// the body, which would read back as uncompressed.
+	failed := false
+	for n := range len(random) {
+		a := &Archive{Compressed: true}
+		a.Put("a", append(random[:n:n], make([]byte, len(random)-n)...))
+
+		buffer := jagbuf.NewBuffer()
+		if err := a.Encode(buffer); err != nil {
+			if !errors.Is(err, errCompressedLength) {
+				t.Fatalf("Encode fail: expected errCompressedLength, got %v", err)
+			}
+			failed = true
+			continue
+		}
+
+		if decoded, err := Decode(buffer); err != nil || !decoded.Compressed {
+			t.Errorf("Decode fail: %d random bytes: expected a compressed archive, got %v", n, err)
+		}
+	}
+
+	if !failed {
+		t.Error("Encode fail: expected a body compressed to its own length to be rejected")
+	}
+}
+
+func TestDecode_CompressedRaw(t *testing.T) {
+	body := []byte{
+		0, 1, // file count
+		0, 0, 0, 33, // name hash of "a"
+		0, 0, 5, 0, 0, 3, // file lengths
+		'a', 'b', 'c',
+	}
+
+	compressed := jagbuf.NewBuffer()
+	bzip2.Compress(compressed, body)
+
+	buffer := jagbuf.NewBuffer()
+	buffer.WriteUint24(uint32(len(body)))
+	buffer.WriteUint24(uint32(compressed.ReadableBytes()))
+	buffer.Write(compressed.Bytes())
+
+	// The files of a compressed archive are never decompressed.
+	a, err := Decode(buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !a.Compressed || !bytes.Equal(a.Files[0].Data, []byte("abc")) {
+		t.Errorf("Decode fail: expected \"abc\" read as it is, got %q", a.Files[0].Data)
+	}
+}
+
+func TestArchive_Layout(t *testing.T) {
+	a := &Archive{}
+	a.Put("a", []byte("xy"))
+
+	expected := []byte{
+		0, 0, 14, 0, 0, 14, // body lengths
+		0, 1, // file count
+		0, 0, 0, 33, // name hash of "a"
+		0, 0, 2, 0, 0, 2, // file lengths
+		'x', 'y',
+	}
+
+	buffer := jagbuf.NewBuffer()
+	if err := a.Encode(buffer); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("Encode fail: expected %x, got %x", expected, buffer.Bytes())
+	}
+}
+
+func TestArchive_Lookup(t *testing.T) {
+	a := testArchive(true)
+
+	if f := a.File("INDEX.DAT"); f == nil || !bytes.Equal(f.Data, []byte{1, 2, 3}) {
+		t.Errorf("File fail: expected index.dat, got %v", f)
+	}
+	if f := a.FileByNameHash(jagbuf.JagNameHash("logo.dat")); f == nil || f != &a.Files[0] {
+		t.Errorf("FileByNameHash fail: expected logo.dat, got %v", f)
+	}
+	if f := a.File("missing.dat"); f != nil {
+		t.Errorf("File fail: expected nil, got %v", f)
+	}
+
+	a.Put("Index.dat", []byte{4})
+	if len(a.Files) != 4 || !bytes.Equal(a.File("index.dat").Data, []byte{4}) {
+		t.Errorf("Put fail: expected index.dat to be replaced")
+	}
+}
+
+func TestDecode_Errors(t *testing.T) {
+	buffer := jagbuf.NewBuffer()
+	_ = testArchive(false).Encode(buffer)
+	valid := buffer.Bytes()
+
+	if _, err := Decode(jagbuf.Wrap(valid[:len(valid)-1])); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Decode fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	tests := map[string][]byte{
+		"empty":     {},
+		"header":    {0, 0, 1},
+		"directory": {0, 0, 2, 0, 0, 2, 0xFF, 0xFF},
+		"bzip2":     {0, 0, 9, 0, 0, 3, 1, 2, 3},
+	}
+
+	for name, data := range tests {
+		if _, err := Decode(jagbuf.Wrap(data)); err == nil {
+			t.Errorf("Decode fail: expected an error for %s", name)
+		}
+	}
+}
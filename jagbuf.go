@@ -44,12 +44,23 @@ func (b *Buffer) Capacity() int {
 // Grow allocates a new buffer with capacity expanded by n and copies
 // the existing data into it.
 func (b *Buffer) Grow(n int) {
-	b.data = append(make([]byte, len(b.data), cap(b.data)+n), b.data...)
+	data := make([]byte, cap(b.data)+n)
+	copy(data, b.data)
+	b.data = data
 }
 
+// ensureWritable is kept small enough to be inlined into every Write*
+// method; the growth itself happens out of line in grow.
 func (b *Buffer) ensureWritable(numBytes int) {
-	// For zero value buffers
-	if b.data == nil {
+	if b.WritableBytes() < numBytes {
+		b.grow(numBytes)
+	}
+}
+
+//go:noinline
+func (b *Buffer) grow(numBytes int) {
+	// For zero value (and zero capacity) buffers
+	if b.Capacity() == 0 {
 		b.data = make([]byte, numBytes)
 		return
 	}
@@ -111,9 +122,9 @@ func (b *Buffer) Read(dst []byte) (int, error) {
 		return 0, io.EOF
 	}
 
-	read := copy(dst, b.data[b.readIndex:])
+	read := copy(dst, b.data[b.readIndex:b.writeIndex])
 
-	defer func() { b.readIndex += read }()
+	b.readIndex += read
 	return read, nil
 }
 
@@ -124,6 +135,6 @@ func (b *Buffer) Write(data []byte) int {
 
 	written := copy(b.data[b.writeIndex:], data)
 
-	defer func() { b.writeIndex += written }()
+	b.writeIndex += written
 	return written
 }
@@ -0,0 +1,131 @@
+package jagbuf
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// MaxBase37Len is the longest name that can be encoded in base 37.
+	MaxBase37Len = 12
+
+	// maxBase37 is 37^12, the first value too large to be a base 37 name.
+	maxBase37 = 0x5B5B57F8A98A5DD1
+)
+
+// ErrInvalidBase37 is returned by ReadBase37 for a value that is not an
+// encoded name.
+var ErrInvalidBase37 = errors.New("jagbuf: invalid base37 name")
+
+var base37Chars = [37]byte{
+	'_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l',
+	'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y',
+	'z', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9',
+}
+
+// base37Digit returns the digit for c, where any character other than a
+// letter or number is encoded as an underscore.
+func base37Digit(c rune) byte {
+	switch {
+	case c >= 'a' && c <= 'z':
+		return byte(c-'a') + 1
+	case c >= 'A' && c <= 'Z':
+		return byte(c-'A') + 1
+	case c >= '0' && c <= '9':
+		return byte(c-'0') + 27
+	}
+	return 0
+}
+
+// base37Digits returns the digits of the first MaxBase37Len characters of
+// name, and how many there are. Characters are counted as UTF-16 code units
+// like in the client, so one outside the Basic Multilingual Plane takes two.
+func base37Digits(name string) ([MaxBase37Len]byte, int) {
+	var digits [MaxBase37Len]byte
+	n := 0
+	for _, c := range name {
+		for range utf16.RuneLen(c) {
+			if n == MaxBase37Len {
+				return digits, n
+			}
+			digits[n] = base37Digit(c)
+			n++
+		}
+	}
+	return digits, n
+}
+
+// EncodeBase37 encodes a player name as a base 37 long, the way older
+// revisions send names in friends lists and private messages. Only the first
+// MaxBase37Len characters are encoded, letters are case insensitive and
+// anything other than a letter or number becomes an underscore. Leading and
+// trailing underscores are lost.
+func EncodeBase37(name string) uint64 {
+	digits, n := base37Digits(name)
+
+	var v uint64
+	for _, digit := range digits[:n] {
+		v = v*37 + uint64(digit)
+	}
+
+	for v != 0 && v%37 == 0 {
+		v /= 37
+	}
+
+	return v
+}
+
+// DecodeBase37 decodes a name encoded by EncodeBase37. It returns an empty
+// string for values that are not a valid encoding.
+func DecodeBase37(v uint64) string {
+	if v == 0 || v >= maxBase37 || v%37 == 0 {
+		return ""
+	}
+
+	var name [MaxBase37Len]byte
+	i := len(name)
+	for v != 0 {
+		i--
+		name[i] = base37Chars[v%37]
+		v /= 37
+	}
+
+	return string(name[i:])
+}
+
+// NormalizeBase37 returns name as it would be after a round trip through
+// EncodeBase37 and DecodeBase37: lower case, truncated to MaxBase37Len
+// characters, with spaces and other symbols replaced by underscores and
+// leading and trailing underscores removed.
+func NormalizeBase37(name string) string {
+	digits, n := base37Digits(name)
+	for i, digit := range digits[:n] {
+		digits[i] = base37Chars[digit]
+	}
+
+	return strings.Trim(string(digits[:n]), "_")
+}
+
+// ReadBase37 reads a name encoded as a base 37 long, failing with
+// ErrInvalidBase37 if the value is not a valid encoding. The value is only
+// consumed if it is valid.
+func (b *Buffer) ReadBase37() (string, error) {
+	v, err := b.PeekUint64()
+	if err != nil {
+		return "", err
+	}
+
+	name := DecodeBase37(v)
+	if name == "" {
+		return "", ErrInvalidBase37
+	}
+
+	b.readIndex += 8
+	return name, nil
+}
+
+// WriteBase37 writes name encoded as a base 37 long.
+func (b *Buffer) WriteBase37(name string) {
+	b.WriteUint64(EncodeBase37(name))
+}
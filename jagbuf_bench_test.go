@@ -0,0 +1,386 @@
+package jagbuf
+
+import (
+	"io"
+	"testing"
+)
+
+// benchOps is the number of values read or written per benchmark iteration.
+// Running a full pass per iteration keeps the closure call out of the
+// per-value cost, so the compiler is free to inline the method under test.
+const benchOps = 1024
+
+func benchmarkRead(b *testing.B, size int, pass func(*Buffer)) {
+	buffer := Wrap(make([]byte, size*benchOps))
+
+	b.SetBytes(int64(size * benchOps))
+	b.ReportAllocs()
+	for b.Loop() {
+		buffer.ResetReadIndex()
+		pass(buffer)
+	}
+}
+
+func benchmarkWrite(b *testing.B, size int, pass func(*Buffer)) {
+	buffer := NewWithCapacity(size * benchOps)
+
+	b.SetBytes(int64(size * benchOps))
+	b.ReportAllocs()
+	for b.Loop() {
+		buffer.ResetWriteIndex()
+		pass(buffer)
+	}
+}
+
+func BenchmarkReadUint8(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkRead(b, 1, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = buf.ReadUint8()
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkRead(b, 1, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = deferReadUint8(buf)
+			}
+		})
+	})
+}
+
+func BenchmarkReadUint16(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkRead(b, 2, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = buf.ReadUint16()
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkRead(b, 2, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = deferReadUint16(buf)
+			}
+		})
+	})
+}
+
+func BenchmarkReadUint24(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkRead(b, 3, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = buf.ReadUint24()
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkRead(b, 3, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = deferReadUint24(buf)
+			}
+		})
+	})
+}
+
+func BenchmarkReadUint32(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkRead(b, 4, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = buf.ReadUint32()
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkRead(b, 4, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = deferReadUint32(buf)
+			}
+		})
+	})
+}
+
+func BenchmarkReadUint32V2(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkRead(b, 4, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = buf.ReadUint32V2()
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkRead(b, 4, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = deferReadUint32V2(buf)
+			}
+		})
+	})
+}
+
+func BenchmarkReadUint64(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkRead(b, 8, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = buf.ReadUint64()
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkRead(b, 8, func(buf *Buffer) {
+			for range benchOps {
+				_, _ = deferReadUint64(buf)
+			}
+		})
+	})
+}
+
+func BenchmarkWriteUint8(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkWrite(b, 1, func(buf *Buffer) {
+			for i := range benchOps {
+				buf.WriteUint8(uint8(i))
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkWrite(b, 1, func(buf *Buffer) {
+			for i := range benchOps {
+				deferWriteUint8(buf, uint8(i))
+			}
+		})
+	})
+}
+
+func BenchmarkWriteUint16(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkWrite(b, 2, func(buf *Buffer) {
+			for i := range benchOps {
+				buf.WriteUint16(uint16(i))
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkWrite(b, 2, func(buf *Buffer) {
+			for i := range benchOps {
+				deferWriteUint16(buf, uint16(i))
+			}
+		})
+	})
+}
+
+func BenchmarkWriteUint24(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkWrite(b, 3, func(buf *Buffer) {
+			for i := range benchOps {
+				buf.WriteUint24(uint32(i))
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkWrite(b, 3, func(buf *Buffer) {
+			for i := range benchOps {
+				deferWriteUint24(buf, uint32(i))
+			}
+		})
+	})
+}
+
+func BenchmarkWriteUint32(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkWrite(b, 4, func(buf *Buffer) {
+			for i := range benchOps {
+				buf.WriteUint32(uint32(i))
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkWrite(b, 4, func(buf *Buffer) {
+			for i := range benchOps {
+				deferWriteUint32(buf, uint32(i))
+			}
+		})
+	})
+}
+
+func BenchmarkWriteUint32V2(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkWrite(b, 4, func(buf *Buffer) {
+			for i := range benchOps {
+				buf.WriteUint32V2(uint32(i))
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkWrite(b, 4, func(buf *Buffer) {
+			for i := range benchOps {
+				deferWriteUint32V2(buf, uint32(i))
+			}
+		})
+	})
+}
+
+func BenchmarkWriteUint64(b *testing.B) {
+	b.Run("impl=fast", func(b *testing.B) {
+		benchmarkWrite(b, 8, func(buf *Buffer) {
+			for i := range benchOps {
+				buf.WriteUint64(uint64(i))
+			}
+		})
+	})
+	b.Run("impl=defer", func(b *testing.B) {
+		benchmarkWrite(b, 8, func(buf *Buffer) {
+			for i := range benchOps {
+				deferWriteUint64(buf, uint64(i))
+			}
+		})
+	})
+}
+
+// The defer* functions below are the original byte-by-byte implementations,
+// kept here so the benchmarks can compare against them.
+
+func deferReadUint8(b *Buffer) (uint8, error) {
+	if b.ReadableBytes() < 1 {
+		return 0, io.EOF
+	}
+
+	val := b.data[b.readIndex]
+
+	defer func() { b.readIndex += 1 }()
+	return val, nil
+}
+
+func deferReadUint16(b *Buffer) (uint16, error) {
+	if b.ReadableBytes() < 2 {
+		return 0, io.EOF
+	}
+
+	val := uint16(b.data[b.readIndex]) << 8
+	val |= uint16(b.data[b.readIndex+1])
+
+	defer func() { b.readIndex += 2 }()
+	return val, nil
+}
+
+func deferReadUint24(b *Buffer) (uint32, error) {
+	if b.ReadableBytes() < 3 {
+		return 0, io.EOF
+	}
+
+	val := uint32(b.data[b.readIndex]) << 16
+	val |= uint32(b.data[b.readIndex+1]) << 8
+	val |= uint32(b.data[b.readIndex+2])
+
+	defer func() { b.readIndex += 3 }()
+	return val, nil
+}
+
+func deferReadUint32(b *Buffer) (uint32, error) {
+	if b.ReadableBytes() < 4 {
+		return 0, io.EOF
+	}
+
+	val := uint32(b.data[b.readIndex]) << 24
+	val |= uint32(b.data[b.readIndex+1]) << 16
+	val |= uint32(b.data[b.readIndex+2]) << 8
+	val |= uint32(b.data[b.readIndex+3])
+
+	defer func() { b.readIndex += 4 }()
+	return val, nil
+}
+
+func deferReadUint32V2(b *Buffer) (uint32, error) {
+	if b.ReadableBytes() < 4 {
+		return 0, io.EOF
+	}
+
+	val := uint32(b.data[b.readIndex]) << 16
+	val |= uint32(b.data[b.readIndex+1]) << 24
+	val |= uint32(b.data[b.readIndex+2])
+	val |= uint32(b.data[b.readIndex+3]) << 8
+
+	defer func() { b.readIndex += 4 }()
+	return val, nil
+}
+
+func deferReadUint64(b *Buffer) (uint64, error) {
+	if b.ReadableBytes() < 8 {
+		return 0, io.EOF
+	}
+
+	val := uint64(b.data[b.readIndex]) << 56
+	val |= uint64(b.data[b.readIndex+1]) << 48
+	val |= uint64(b.data[b.readIndex+2]) << 40
+	val |= uint64(b.data[b.readIndex+3]) << 32
+	val |= uint64(b.data[b.readIndex+4]) << 24
+	val |= uint64(b.data[b.readIndex+5]) << 16
+	val |= uint64(b.data[b.readIndex+6]) << 8
+	val |= uint64(b.data[b.readIndex+7])
+
+	defer func() { b.readIndex += 8 }()
+	return val, nil
+}
+
+func deferWriteUint8(b *Buffer, v uint8) {
+	b.ensureWritable(1)
+
+	b.data[b.writeIndex] = v
+
+	defer func() { b.writeIndex += 1 }()
+}
+
+func deferWriteUint16(b *Buffer, v uint16) {
+	b.ensureWritable(2)
+
+	b.data[b.writeIndex] = byte(v >> 8)
+	b.data[b.writeIndex+1] = byte(v & 0xFF)
+
+	defer func() { b.writeIndex += 2 }()
+}
+
+func deferWriteUint24(b *Buffer, v uint32) {
+	b.ensureWritable(3)
+
+	b.data[b.writeIndex] = byte(v >> 16)
+	b.data[b.writeIndex+1] = byte(v >> 8)
+	b.data[b.writeIndex+2] = byte(v & 0xFF)
+
+	defer func() { b.writeIndex += 3 }()
+}
+
+func deferWriteUint32(b *Buffer, v uint32) {
+	b.ensureWritable(4)
+
+	b.data[b.writeIndex] = byte(v >> 24)
+	b.data[b.writeIndex+1] = byte(v >> 16)
+	b.data[b.writeIndex+2] = byte(v >> 8)
+	b.data[b.writeIndex+3] = byte(v & 0xFF)
+
+	defer func() { b.writeIndex += 4 }()
+}
+
+func deferWriteUint32V2(b *Buffer, v uint32) {
+	b.ensureWritable(4)
+
+	b.data[b.writeIndex] = byte(v >> 16)
+	b.data[b.writeIndex+1] = byte(v >> 24)
+	b.data[b.writeIndex+2] = byte(v & 0xFF)
+	b.data[b.writeIndex+3] = byte(v >> 8)
+
+	defer func() { b.writeIndex += 4 }()
+}
+
+func deferWriteUint64(b *Buffer, v uint64) {
+	b.ensureWritable(8)
+
+	b.data[b.writeIndex] = byte(v >> 56)
+	b.data[b.writeIndex+1] = byte(v >> 48)
+	b.data[b.writeIndex+2] = byte(v >> 40)
+	b.data[b.writeIndex+3] = byte(v >> 32)
+	b.data[b.writeIndex+4] = byte(v >> 24)
+	b.data[b.writeIndex+5] = byte(v >> 16)
+	b.data[b.writeIndex+6] = byte(v >> 8)
+	b.data[b.writeIndex+7] = byte(v & 0xFF)
+
+	defer func() { b.writeIndex += 8 }()
+}
@@ -0,0 +1,48 @@
+package jagbuf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidBool is returned by ReadBoolStrict for a byte other than 0 or 1.
+var ErrInvalidBool = errors.New("jagbuf: invalid bool")
+
+// ReadBool reads a single byte as a bool, treating any non-zero value as
+// true like the client does.
+func (b *Buffer) ReadBool() (bool, error) {
+	val, err := b.ReadUint8()
+	return val != 0, err
+}
+
+// ReadBoolStrict reads a single byte as a bool, failing with an error
+// wrapping ErrInvalidBool if it is not 0 or 1. The byte is only consumed if
+// it is valid.
+func (b *Buffer) ReadBoolStrict() (bool, error) {
+	if b.ReadableBytes() < 1 {
+		return false, io.EOF
+	}
+
+	if err := checkBool(b.data[b.readIndex]); err != nil {
+		return false, err
+	}
+
+	return b.ReadBool()
+}
+
+func checkBool(c byte) error {
+	if c > 1 {
+		return fmt.Errorf("%w: 0x%02x", ErrInvalidBool, c)
+	}
+	return nil
+}
+
+// WriteBool writes v as a single byte, 1 for true and 0 for false.
+func (b *Buffer) WriteBool(v bool) {
+	if v {
+		b.WriteUint8(1)
+	} else {
+		b.WriteUint8(0)
+	}
+}
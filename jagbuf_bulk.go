@@ -0,0 +1,239 @@
+package jagbuf
+
+import "io"
+
+type integer interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32 | ~uint64
+}
+
+// readInts fills dst with n byte wide integers, sign extending each one when
+// signed is set. Nothing is consumed unless the whole of dst can be filled.
+func readInts[T integer](b *Buffer, dst []T, n int, signed bool, o Order, t Transform) error {
+	checkLayout(n, o, t)
+
+	size := len(dst) * n
+	if b.ReadableBytes() < size {
+		return io.EOF
+	}
+
+	s := b.data[b.readIndex : b.readIndex+size]
+	for i := range dst {
+		val := t.decode(getUint(s[i*n:i*n+n], o))
+		if signed {
+			dst[i] = T(signExtend(val, n))
+		} else {
+			dst[i] = T(val)
+		}
+	}
+
+	b.readIndex += size
+	return nil
+}
+
+func writeInts[T integer](b *Buffer, src []T, n int, o Order, t Transform) {
+	checkLayout(n, o, t)
+
+	size := len(src) * n
+	b.ensureWritable(size)
+
+	s := b.data[b.writeIndex : b.writeIndex+size]
+	for i, v := range src {
+		putUint(s[i*n:i*n+n], t.encode(uint64(v)), o)
+	}
+
+	b.writeIndex += size
+}
+
+// ReadUint8s fills dst with bytes from the buffer, undoing transform t on
+// each one.
+func (b *Buffer) ReadUint8s(dst []uint8, t Transform) error {
+	return readInts(b, dst, 1, false, BigEndian, t)
+}
+
+// ReadInt8s fills dst with bytes from the buffer, undoing transform t on
+// each one.
+func (b *Buffer) ReadInt8s(dst []int8, t Transform) error {
+	return readInts(b, dst, 1, true, BigEndian, t)
+}
+
+// ReadUint16s fills dst with uint16s in order o, undoing transform t on each
+// one. Nothing is read unless the buffer holds enough data for all of dst.
+func (b *Buffer) ReadUint16s(dst []uint16, o Order, t Transform) error {
+	return readInts(b, dst, 2, false, o, t)
+}
+
+// ReadInt16s fills dst with int16s in order o, undoing transform t on each
+// one. Nothing is read unless the buffer holds enough data for all of dst.
+func (b *Buffer) ReadInt16s(dst []int16, o Order, t Transform) error {
+	return readInts(b, dst, 2, true, o, t)
+}
+
+// ReadUint24s fills dst with 24-bit unsigned integers in order o, undoing
+// transform t on each one.
+func (b *Buffer) ReadUint24s(dst []uint32, o Order, t Transform) error {
+	return readInts(b, dst, 3, false, o, t)
+}
+
+// ReadInt24s fills dst with sign extended 24-bit integers in order o,
+// undoing transform t on each one.
+func (b *Buffer) ReadInt24s(dst []int32, o Order, t Transform) error {
+	return readInts(b, dst, 3, true, o, t)
+}
+
+// ReadUint32s fills dst with uint32s in order o, undoing transform t on each
+// one. Nothing is read unless the buffer holds enough data for all of dst.
+func (b *Buffer) ReadUint32s(dst []uint32, o Order, t Transform) error {
+	return readInts(b, dst, 4, false, o, t)
+}
+
+// ReadInt32s fills dst with int32s in order o, undoing transform t on each
+// one. Nothing is read unless the buffer holds enough data for all of dst.
+func (b *Buffer) ReadInt32s(dst []int32, o Order, t Transform) error {
+	return readInts(b, dst, 4, true, o, t)
+}
+
+// ReadUint64s fills dst with uint64s in order o, undoing transform t on each
+// one. Nothing is read unless the buffer holds enough data for all of dst.
+func (b *Buffer) ReadUint64s(dst []uint64, o Order, t Transform) error {
+	return readInts(b, dst, 8, false, o, t)
+}
+
+// ReadInt64s fills dst with int64s in order o, undoing transform t on each
+// one. Nothing is read unless the buffer holds enough data for all of dst.
+func (b *Buffer) ReadInt64s(dst []int64, o Order, t Transform) error {
+	return readInts(b, dst, 8, true, o, t)
+}
+
+// WriteUint8s writes every byte of src, applying transform t to each one.
+func (b *Buffer) WriteUint8s(src []uint8, t Transform) {
+	writeInts(b, src, 1, BigEndian, t)
+}
+
+// WriteInt8s writes every byte of src, applying transform t to each one.
+func (b *Buffer) WriteInt8s(src []int8, t Transform) {
+	writeInts(b, src, 1, BigEndian, t)
+}
+
+// WriteUint16s writes every value of src in order o, applying transform t
+// to each one.
+func (b *Buffer) WriteUint16s(src []uint16, o Order, t Transform) {
+	writeInts(b, src, 2, o, t)
+}
+
+// WriteInt16s writes every value of src in order o, applying transform t to
+// each one.
+func (b *Buffer) WriteInt16s(src []int16, o Order, t Transform) {
+	writeInts(b, src, 2, o, t)
+}
+
+// WriteUint24s writes the low 24 bits of every value of src in order o,
+// applying transform t to each one.
+func (b *Buffer) WriteUint24s(src []uint32, o Order, t Transform) {
+	writeInts(b, src, 3, o, t)
+}
+
+// WriteInt24s writes the low 24 bits of every value of src in order o,
+// applying transform t to each one.
+func (b *Buffer) WriteInt24s(src []int32, o Order, t Transform) {
+	writeInts(b, src, 3, o, t)
+}
+
+// WriteUint32s writes every value of src in order o, applying transform t
+// to each one.
+func (b *Buffer) WriteUint32s(src []uint32, o Order, t Transform) {
+	writeInts(b, src, 4, o, t)
+}
+
+// WriteInt32s writes every value of src in order o, applying transform t to
+// each one.
+func (b *Buffer) WriteInt32s(src []int32, o Order, t Transform) {
+	writeInts(b, src, 4, o, t)
+}
+
+// WriteUint64s writes every value of src in order o, applying transform t
+// to each one.
+func (b *Buffer) WriteUint64s(src []uint64, o Order, t Transform) {
+	writeInts(b, src, 8, o, t)
+}
+
+// WriteInt64s writes every value of src in order o, applying transform t to
+// each one.
+func (b *Buffer) WriteInt64s(src []int64, o Order, t Transform) {
+	writeInts(b, src, 8, o, t)
+}
+
+// ReadBytes fills dst with bytes from the buffer. Unlike Read, this fails
+// with io.EOF and reads nothing if dst cannot be filled completely.
+func (b *Buffer) ReadBytes(dst []byte) error {
+	if b.ReadableBytes() < len(dst) {
+		return io.EOF
+	}
+
+	b.readIndex += copy(dst, b.data[b.readIndex:b.writeIndex])
+	return nil
+}
+
+// ReadBytesAdd fills dst with bytes from the buffer, applying the
+// `value - 128` transform to each one.
+func (b *Buffer) ReadBytesAdd(dst []byte) error {
+	return b.ReadUint8s(dst, TransformAdd)
+}
+
+// ReadBytesReversed fills dst with bytes from the buffer in reverse order,
+// so the last byte read ends up in dst[0].
+func (b *Buffer) ReadBytesReversed(dst []byte) error {
+	if err := b.ReadBytes(dst); err != nil {
+		return err
+	}
+
+	reverse(dst)
+	return nil
+}
+
+// ReadBytesReversedAdd fills dst with bytes from the buffer in reverse
+// order, applying the `value - 128` transform to each one.
+func (b *Buffer) ReadBytesReversedAdd(dst []byte) error {
+	if err := b.ReadBytesAdd(dst); err != nil {
+		return err
+	}
+
+	reverse(dst)
+	return nil
+}
+
+// WriteBytesAdd writes every byte of src applying the `value + 128`
+// transform.
+func (b *Buffer) WriteBytesAdd(src []byte) {
+	b.WriteUint8s(src, TransformAdd)
+}
+
+// WriteBytesReversed writes every byte of src, last byte first.
+func (b *Buffer) WriteBytesReversed(src []byte) {
+	b.ensureWritable(len(src))
+
+	s := b.data[b.writeIndex : b.writeIndex+len(src)]
+	for i, v := range src {
+		s[len(s)-1-i] = v
+	}
+
+	b.writeIndex += len(src)
+}
+
+// WriteBytesReversedAdd writes every byte of src, last byte first, applying
+// the `value + 128` transform.
+func (b *Buffer) WriteBytesReversedAdd(src []byte) {
+	b.ensureWritable(len(src))
+
+	s := b.data[b.writeIndex : b.writeIndex+len(src)]
+	for i, v := range src {
+		s[len(s)-1-i] = v + 128
+	}
+
+	b.writeIndex += len(src)
+}
+
+func reverse(s []byte) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
@@ -0,0 +1,392 @@
+package jagbuf
+
+import (
+	"cmp"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// Codec encodes and decodes values of type T. Codecs for the primitives are
+// provided as package variables, and combined into codecs for slices, maps,
+// optional values and structs with Slice, Map, Optional and Struct.
+//
+//	type Param struct {
+//		ID    uint32
+//		Value int32
+//	}
+//
+//	var paramCodec = jagbuf.Struct(
+//		jagbuf.NewField(func(p *Param) *uint32 { return &p.ID }, jagbuf.Uint24),
+//		jagbuf.NewField(func(p *Param) *int32 { return &p.Value }, jagbuf.Int32),
+//	)
+//
+//	var paramsCodec = jagbuf.Slice(jagbuf.Uint8, paramCodec)
+type Codec[T any] interface {
+	Encode(b *Buffer, v T) error
+	Decode(b *Buffer) (T, error)
+}
+
+// primitive is a Codec for a type with a Buffer method to read it and one to
+// write it. check, when set, rejects values the write method cannot encode.
+type primitive[T any] struct {
+	write func(*Buffer, T)
+	read  func(*Buffer) (T, error)
+	check func(T) error
+}
+
+func (c primitive[T]) Encode(b *Buffer, v T) error {
+	if c.check != nil {
+		if err := c.check(v); err != nil {
+			return err
+		}
+	}
+
+	c.write(b, v)
+	return nil
+}
+
+func (c primitive[T]) Decode(b *Buffer) (T, error) {
+	return c.read(b)
+}
+
+// inRange returns a check that v is between lo and hi.
+func inRange[T integer](lo, hi T) func(T) error {
+	return func(v T) error {
+		if v < lo || v > hi {
+			return fmt.Errorf("jagbuf: value %d out of range", v)
+		}
+		return nil
+	}
+}
+
+// Codecs for the primitives, named after the Buffer methods they use.
+var (
+	Uint8 Codec[uint8] = primitive[uint8]{write: (*Buffer).WriteUint8, read: (*Buffer).ReadUint8}
+	Int8  Codec[int8]  = primitive[int8]{write: (*Buffer).WriteInt8, read: (*Buffer).ReadInt8}
+
+	Uint8_Sub    Codec[uint8] = transformed(1, BigEndian, TransformAdd, (*Buffer).ReadUint8_Sub)
+	Uint8_Neg    Codec[uint8] = transformed(1, BigEndian, TransformNeg, (*Buffer).ReadUint8_Neg)
+	Uint8_Mirror Codec[uint8] = transformed(1, BigEndian, TransformMirror, (*Buffer).ReadUint8_Mirror)
+	Int8_Sub     Codec[int8]  = transformed(1, BigEndian, TransformAdd, (*Buffer).ReadInt8_Sub)
+	Int8_Neg     Codec[int8]  = transformed(1, BigEndian, TransformNeg, (*Buffer).ReadInt8_Neg)
+	Int8_Mirror  Codec[int8]  = transformed(1, BigEndian, TransformMirror, (*Buffer).ReadInt8_Mirror)
+
+	Uint16   Codec[uint16] = primitive[uint16]{write: (*Buffer).WriteUint16, read: (*Buffer).ReadUint16}
+	Uint16LE Codec[uint16] = primitive[uint16]{write: (*Buffer).WriteUint16LE, read: (*Buffer).ReadUint16LE}
+	Int16    Codec[int16]  = primitive[int16]{write: (*Buffer).WriteInt16, read: (*Buffer).ReadInt16}
+	Int16LE  Codec[int16]  = primitive[int16]{write: (*Buffer).WriteInt16LE, read: (*Buffer).ReadInt16LE}
+
+	Uint16_Sub   Codec[uint16] = transformed(2, BigEndian, TransformAdd, (*Buffer).ReadUint16_Sub)
+	Uint16LE_Sub Codec[uint16] = transformed(2, LittleEndian, TransformAdd, (*Buffer).ReadUint16LE_Sub)
+
+	Uint24   Codec[uint32] = primitive[uint32]{write: (*Buffer).WriteUint24, read: (*Buffer).ReadUint24, check: inRange[uint32](0, 1<<24-1)}
+	Uint24LE Codec[uint32] = primitive[uint32]{write: (*Buffer).WriteUint24LE, read: (*Buffer).ReadUint24LE, check: inRange[uint32](0, 1<<24-1)}
+	Int24    Codec[int32]  = primitive[int32]{write: (*Buffer).WriteInt24, read: (*Buffer).ReadInt24, check: inRange[int32](-1<<23, 1<<23-1)}
+	Int24LE  Codec[int32]  = primitive[int32]{write: (*Buffer).WriteInt24LE, read: (*Buffer).ReadInt24LE, check: inRange[int32](-1<<23, 1<<23-1)}
+
+	Uint32   Codec[uint32] = primitive[uint32]{write: (*Buffer).WriteUint32, read: (*Buffer).ReadUint32}
+	Uint32LE Codec[uint32] = primitive[uint32]{write: (*Buffer).WriteUint32LE, read: (*Buffer).ReadUint32LE}
+	Uint32V1 Codec[uint32] = primitive[uint32]{write: (*Buffer).WriteUint32V1, read: (*Buffer).ReadUint32V1}
+	Uint32V2 Codec[uint32] = primitive[uint32]{write: (*Buffer).WriteUint32V2, read: (*Buffer).ReadUint32V2}
+	Int32    Codec[int32]  = primitive[int32]{write: (*Buffer).WriteInt32, read: (*Buffer).ReadInt32}
+	Int32LE  Codec[int32]  = primitive[int32]{write: (*Buffer).WriteInt32LE, read: (*Buffer).ReadInt32LE}
+	Int32V1  Codec[int32]  = primitive[int32]{write: (*Buffer).WriteInt32V1, read: (*Buffer).ReadInt32V1}
+	Int32V2  Codec[int32]  = primitive[int32]{write: (*Buffer).WriteInt32V2, read: (*Buffer).ReadInt32V2}
+
+	Uint40   Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint40, read: (*Buffer).ReadUint40, check: inRange[uint64](0, 1<<40-1)}
+	Uint40LE Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint40LE, read: (*Buffer).ReadUint40LE, check: inRange[uint64](0, 1<<40-1)}
+	Int40    Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt40, read: (*Buffer).ReadInt40, check: inRange[int64](-1<<39, 1<<39-1)}
+	Int40LE  Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt40LE, read: (*Buffer).ReadInt40LE, check: inRange[int64](-1<<39, 1<<39-1)}
+
+	Uint48   Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint48, read: (*Buffer).ReadUint48, check: inRange[uint64](0, 1<<48-1)}
+	Uint48LE Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint48LE, read: (*Buffer).ReadUint48LE, check: inRange[uint64](0, 1<<48-1)}
+	Int48    Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt48, read: (*Buffer).ReadInt48, check: inRange[int64](-1<<47, 1<<47-1)}
+	Int48LE  Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt48LE, read: (*Buffer).ReadInt48LE, check: inRange[int64](-1<<47, 1<<47-1)}
+
+	Uint56   Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint56, read: (*Buffer).ReadUint56, check: inRange[uint64](0, 1<<56-1)}
+	Uint56LE Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint56LE, read: (*Buffer).ReadUint56LE, check: inRange[uint64](0, 1<<56-1)}
+	Int56    Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt56, read: (*Buffer).ReadInt56, check: inRange[int64](-1<<55, 1<<55-1)}
+	Int56LE  Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt56LE, read: (*Buffer).ReadInt56LE, check: inRange[int64](-1<<55, 1<<55-1)}
+
+	Uint64   Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint64, read: (*Buffer).ReadUint64}
+	Uint64LE Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteUint64LE, read: (*Buffer).ReadUint64LE}
+	Int64    Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt64, read: (*Buffer).ReadInt64}
+	Int64LE  Codec[int64]  = primitive[int64]{write: (*Buffer).WriteInt64LE, read: (*Buffer).ReadInt64LE}
+
+	Smart       Codec[uint16] = primitive[uint16]{write: (*Buffer).WriteSmart, read: (*Buffer).ReadSmart, check: inRange[uint16](0, MaxSmart)}
+	SignedSmart Codec[int16]  = primitive[int16]{write: (*Buffer).WriteSignedSmart, read: (*Buffer).ReadSignedSmart, check: inRange[int16](MinSignedSmart, MaxSignedSmart)}
+
+	VarInt       Codec[uint64] = primitive[uint64]{write: (*Buffer).WriteVarInt, read: (*Buffer).ReadVarInt}
+	SignedVarInt Codec[int64]  = primitive[int64]{write: (*Buffer).WriteSignedVarInt, read: (*Buffer).ReadSignedVarInt}
+
+	String      Codec[string] = primitive[string]{write: (*Buffer).WriteString, read: (*Buffer).ReadString}
+	JagString   Codec[string] = primitive[string]{write: (*Buffer).WriteJagString, read: (*Buffer).ReadJagString}
+	UTF         Codec[string] = primitive[string]{write: (*Buffer).WriteUTF, read: (*Buffer).ReadUTF, check: checkUTF}
+	CESU8String Codec[string] = primitive[string]{write: (*Buffer).WriteCESU8String, read: (*Buffer).ReadCESU8String}
+	Base37      Codec[string] = primitive[string]{write: (*Buffer).WriteBase37, read: (*Buffer).ReadBase37}
+
+	Float32   Codec[float32] = primitive[float32]{write: (*Buffer).WriteFloat32, read: (*Buffer).ReadFloat32}
+	Float32LE Codec[float32] = primitive[float32]{write: (*Buffer).WriteFloat32LE, read: (*Buffer).ReadFloat32LE}
+	Float64   Codec[float64] = primitive[float64]{write: (*Buffer).WriteFloat64, read: (*Buffer).ReadFloat64}
+	Float64LE Codec[float64] = primitive[float64]{write: (*Buffer).WriteFloat64LE, read: (*Buffer).ReadFloat64LE}
+
+	Bool       Codec[bool] = primitive[bool]{write: (*Buffer).WriteBool, read: (*Buffer).ReadBool}
+	BoolStrict Codec[bool] = primitive[bool]{write: (*Buffer).WriteBool, read: (*Buffer).ReadBoolStrict}
+)
+
+// transformed returns a Codec for a primitive read by one of the _Sub, _Neg
+// or _Mirror Buffer methods, which have no matching write method, writing it
+// with WriteUintN in the same layout.
+func transformed[T integer](n int, o Order, t Transform, read func(*Buffer) (T, error)) Codec[T] {
+	return primitive[T]{
+		write: func(b *Buffer, v T) { b.WriteUintN(n, uint64(v), o, t) },
+		read:  read,
+	}
+}
+
+func checkUTF(s string) error {
+	if n := modifiedUTF8Len(s, true); n > MaxUTFLen {
+		return fmt.Errorf("jagbuf: UTF string of %d bytes too long", n)
+	}
+	return nil
+}
+
+// Uint returns a Codec for n byte wide unsigned integers in any layout, as
+// read by ReadUintN. It panics if the layout is invalid.
+func Uint(n int, o Order, t Transform) Codec[uint64] {
+	checkLayout(n, o, t)
+
+	return primitive[uint64]{
+		write: func(b *Buffer, v uint64) { b.WriteUintN(n, v, o, t) },
+		read:  func(b *Buffer) (uint64, error) { return b.ReadUintN(n, o, t) },
+		check: inRange[uint64](0, 1<<(8*n)-1),
+	}
+}
+
+// Int returns a Codec for n byte wide signed integers in any layout, as read
+// by ReadIntN. It panics if the layout is invalid.
+func Int(n int, o Order, t Transform) Codec[int64] {
+	checkLayout(n, o, t)
+
+	return primitive[int64]{
+		write: func(b *Buffer, v int64) { b.WriteIntN(n, v, o, t) },
+		read:  func(b *Buffer) (int64, error) { return b.ReadIntN(n, o, t) },
+		check: inRange[int64](-1<<(8*n-1), 1<<(8*n-1)-1),
+	}
+}
+
+// encodeLength writes a collection length with the count codec, checking it
+// is representable in N.
+func encodeLength[N integer](b *Buffer, count Codec[N], n int) error {
+	if n < 0 || int64(N(n)) != int64(n) {
+		return fmt.Errorf("jagbuf: length %d out of range", n)
+	}
+
+	return count.Encode(b, N(n))
+}
+
+// decodeLength reads a collection length with the count codec. A length
+// larger than the remaining data is rejected before anything is allocated
+// for it, as every element takes at least a byte.
+func decodeLength[N integer](b *Buffer, count Codec[N]) (int, error) {
+	n, err := count.Decode(b)
+	if err != nil {
+		return 0, err
+	}
+
+	if n < 0 || uint64(n) > uint64(b.ReadableBytes()) {
+		return 0, io.EOF
+	}
+
+	return int(n), nil
+}
+
+type sliceCodec[N integer, T any] struct {
+	count Codec[N]
+	elem  Codec[T]
+}
+
+// Slice returns a Codec for slices, prefixed by their length encoded with
+// count. Decoding assumes every element takes at least a byte, so elem must
+// not encode to nothing, as an empty Struct does: a length larger than the
+// remaining data fails with io.EOF.
+func Slice[N integer, T any](count Codec[N], elem Codec[T]) Codec[[]T] {
+	return sliceCodec[N, T]{count: count, elem: elem}
+}
+
+func (c sliceCodec[N, T]) Encode(b *Buffer, v []T) error {
+	if err := encodeLength(b, c.count, len(v)); err != nil {
+		return err
+	}
+
+	for i, elem := range v {
+		if err := c.elem.Encode(b, elem); err != nil {
+			return fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (c sliceCodec[N, T]) Decode(b *Buffer) ([]T, error) {
+	n, err := decodeLength(b, c.count)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make([]T, n)
+	for i := range v {
+		if v[i], err = c.elem.Decode(b); err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+	}
+
+	return v, nil
+}
+
+type mapCodec[N integer, K cmp.Ordered, V any] struct {
+	count Codec[N]
+	key   Codec[K]
+	value Codec[V]
+}
+
+// Map returns a Codec for maps, prefixed by their length encoded with count
+// and followed by each key and value in turn. Entries are encoded in key
+// order so the output is deterministic, and decoding fails if a key is
+// repeated. As with Slice, decoding assumes every entry takes at least a
+// byte.
+func Map[N integer, K cmp.Ordered, V any](count Codec[N], key Codec[K], value Codec[V]) Codec[map[K]V] {
+	return mapCodec[N, K, V]{count: count, key: key, value: value}
+}
+
+func (c mapCodec[N, K, V]) Encode(b *Buffer, v map[K]V) error {
+	if err := encodeLength(b, c.count, len(v)); err != nil {
+		return err
+	}
+
+	keys := make([]K, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		if err := c.key.Encode(b, k); err != nil {
+			return fmt.Errorf("key %v: %w", k, err)
+		}
+		if err := c.value.Encode(b, v[k]); err != nil {
+			return fmt.Errorf("key %v: %w", k, err)
+		}
+	}
+
+	return nil
+}
+
+func (c mapCodec[N, K, V]) Decode(b *Buffer) (map[K]V, error) {
+	n, err := decodeLength(b, c.count)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make(map[K]V, n)
+	for i := 0; i < n; i++ {
+		key, err := c.key.Decode(b)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		if _, ok := v[key]; ok {
+			return nil, fmt.Errorf("index %d: duplicate key %v", i, key)
+		}
+		if v[key], err = c.value.Decode(b); err != nil {
+			return nil, fmt.Errorf("key %v: %w", key, err)
+		}
+	}
+
+	return v, nil
+}
+
+type optionalCodec[T any] struct {
+	flag Codec[bool]
+	elem Codec[T]
+}
+
+// Optional returns a Codec for values that may be absent, encoded as a flag
+// followed by the value when the flag is set. A nil pointer is absent.
+func Optional[T any](flag Codec[bool], elem Codec[T]) Codec[*T] {
+	return optionalCodec[T]{flag: flag, elem: elem}
+}
+
+func (c optionalCodec[T]) Encode(b *Buffer, v *T) error {
+	if err := c.flag.Encode(b, v != nil); err != nil || v == nil {
+		return err
+	}
+
+	return c.elem.Encode(b, *v)
+}
+
+func (c optionalCodec[T]) Decode(b *Buffer) (*T, error) {
+	present, err := c.flag.Decode(b)
+	if err != nil || !present {
+		return nil, err
+	}
+
+	v, err := c.elem.Decode(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+
+// Field is a field of a struct of type T, for use with Struct.
+type Field[T any] struct {
+	encode func(*Buffer, *T) error
+	decode func(*Buffer, *T) error
+}
+
+// NewField returns a Field encoded with c, where get returns a pointer to the
+// field within the struct.
+func NewField[T, F any](get func(*T) *F, c Codec[F]) Field[T] {
+	return Field[T]{
+		encode: func(b *Buffer, v *T) error {
+			return c.Encode(b, *get(v))
+		},
+		decode: func(b *Buffer, v *T) (err error) {
+			*get(v), err = c.Decode(b)
+			return err
+		},
+	}
+}
+
+type structCodec[T any] struct {
+	fields []Field[T]
+}
+
+// Struct returns a Codec for structs of type T, encoding each of the fields
+// in order.
+func Struct[T any](fields ...Field[T]) Codec[T] {
+	return structCodec[T]{fields: fields}
+}
+
+func (c structCodec[T]) Encode(b *Buffer, v T) error {
+	for i, f := range c.fields {
+		if err := f.encode(b, &v); err != nil {
+			return fmt.Errorf("field %d: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (c structCodec[T]) Decode(b *Buffer) (T, error) {
+	var v T
+	for i, f := range c.fields {
+		if err := f.decode(b, &v); err != nil {
+			var zero T
+			return zero, fmt.Errorf("field %d: %w", i, err)
+		}
+	}
+
+	return v, nil
+}
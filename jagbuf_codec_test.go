@@ -0,0 +1,167 @@
+package jagbuf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+)
+
+type testParam struct {
+	ID      uint32
+	Value   int32
+	Name    string
+	Flags   map[uint16]bool
+	Comment *string
+}
+
+var testParamCodec = Struct(
+	NewField(func(p *testParam) *uint32 { return &p.ID }, Uint24),
+	NewField(func(p *testParam) *int32 { return &p.Value }, Int32V2),
+	NewField(func(p *testParam) *string { return &p.Name }, JagString),
+	NewField(func(p *testParam) *map[uint16]bool { return &p.Flags }, Map(Uint8, Smart, Bool)),
+	NewField(func(p *testParam) **string { return &p.Comment }, Optional(Bool, String)),
+)
+
+func TestCodec_RoundTrip(t *testing.T) {
+	comment := "hello"
+	params := []testParam{
+		{ID: 1, Value: -1, Name: "a", Flags: map[uint16]bool{}, Comment: &comment},
+		{ID: 1<<24 - 1, Value: 1 << 30, Name: "", Flags: map[uint16]bool{1: true, 0x7FFF: false}},
+	}
+
+	codec := Slice(Smart, testParamCodec)
+
+	b := NewBuffer()
+	if err := codec.Encode(b, params); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := codec.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, params) {
+		t.Errorf("Decode() = %+v, want %+v", got, params)
+	}
+	if b.ReadableBytes() != 0 {
+		t.Errorf("%d bytes left after Decode()", b.ReadableBytes())
+	}
+}
+
+func TestCodec_MatchesBufferMethods(t *testing.T) {
+	b := NewBuffer()
+	_ = Uint16LE.Encode(b, 0x1234)
+	_ = Int24.Encode(b, -2)
+	_ = Uint(2, LittleEndian, TransformAdd).Encode(b, 0x1234)
+	_ = Int(1, BigEndian, TransformNeg).Encode(b, -5)
+	_ = Map(Uint8, Uint8, Uint8).Encode(b, map[uint8]uint8{2: 20, 1: 10})
+
+	want := NewBuffer()
+	want.WriteUint16LE(0x1234)
+	want.WriteInt24(-2)
+	want.WriteUintN(2, 0x1234, LittleEndian, TransformAdd)
+	want.WriteIntN(1, -5, BigEndian, TransformNeg)
+	want.Write([]byte{2, 1, 10, 2, 20})
+
+	if !bytes.Equal(b.Bytes(), want.Bytes()) {
+		t.Errorf("Encode() = %v, want %v", b.Bytes(), want.Bytes())
+	}
+}
+
+func TestCodec_Transforms(t *testing.T) {
+	b := NewBuffer()
+	_ = Uint8_Sub.Encode(b, 1)
+	_ = Uint8_Neg.Encode(b, 2)
+	_ = Uint8_Mirror.Encode(b, 3)
+	_ = Int8_Sub.Encode(b, -4)
+	_ = Int8_Neg.Encode(b, -5)
+	_ = Int8_Mirror.Encode(b, -6)
+	_ = Uint16_Sub.Encode(b, 0x1234)
+	_ = Uint16LE_Sub.Encode(b, 0x5678)
+
+	want := NewBuffer()
+	want.WriteUintN(1, 1, BigEndian, TransformAdd)
+	want.WriteUintN(1, 2, BigEndian, TransformNeg)
+	want.WriteUintN(1, 3, BigEndian, TransformMirror)
+	want.WriteIntN(1, -4, BigEndian, TransformAdd)
+	want.WriteIntN(1, -5, BigEndian, TransformNeg)
+	want.WriteIntN(1, -6, BigEndian, TransformMirror)
+	want.WriteUintN(2, 0x1234, BigEndian, TransformAdd)
+	want.WriteUintN(2, 0x5678, LittleEndian, TransformAdd)
+
+	if !bytes.Equal(b.Bytes(), want.Bytes()) {
+		t.Fatalf("Encode() = %v, want %v", b.Bytes(), want.Bytes())
+	}
+
+	for i, codec := range []Codec[uint8]{Uint8_Sub, Uint8_Neg, Uint8_Mirror} {
+		if v, err := codec.Decode(b); err != nil || v != uint8(i+1) {
+			t.Errorf("Decode() = %d, %v, want %d", v, err, i+1)
+		}
+	}
+	for i, codec := range []Codec[int8]{Int8_Sub, Int8_Neg, Int8_Mirror} {
+		if v, err := codec.Decode(b); err != nil || v != int8(-4-i) {
+			t.Errorf("Decode() = %d, %v, want %d", v, err, -4-i)
+		}
+	}
+	if v, err := Uint16_Sub.Decode(b); err != nil || v != 0x1234 {
+		t.Errorf("Uint16_Sub.Decode() = %#x, %v, want 0x1234", v, err)
+	}
+	if v, err := Uint16LE_Sub.Decode(b); err != nil || v != 0x5678 {
+		t.Errorf("Uint16LE_Sub.Decode() = %#x, %v, want 0x5678", v, err)
+	}
+}
+
+func TestCodec_EncodeOutOfRange(t *testing.T) {
+	tests := map[string]error{
+		"uint24":       Uint24.Encode(NewBuffer(), 1<<24),
+		"int24":        Int24.Encode(NewBuffer(), -1<<23-1),
+		"smart":        Smart.Encode(NewBuffer(), MaxSmart+1),
+		"signed smart": SignedSmart.Encode(NewBuffer(), MinSignedSmart-1),
+		"uint":         Uint(3, BigEndian, TransformNone).Encode(NewBuffer(), 1<<24),
+		"int":          Int(2, BigEndian, TransformNone).Encode(NewBuffer(), 1<<15),
+		"slice length": Slice(Uint8, Uint8).Encode(NewBuffer(), make([]uint8, 256)),
+		"element":      Slice(Uint8, Smart).Encode(NewBuffer(), []uint16{MaxSmart + 1}),
+	}
+
+	for name, err := range tests {
+		if err == nil {
+			t.Errorf("%s: Encode() did not fail", name)
+		}
+	}
+}
+
+func TestCodec_DecodeLargeCount(t *testing.T) {
+	b := NewBuffer()
+	b.WriteUint32(0xFFFFFFFF)
+	b.WriteUint8(0)
+
+	if _, err := Slice(Uint32, Uint8).Decode(b); !errors.Is(err, io.EOF) {
+		t.Errorf("Slice.Decode() error = %v, want io.EOF", err)
+	}
+
+	b = Wrap([]byte{0x7F, 0})
+	if _, err := Map(Int8, Uint8, Uint8).Decode(b); !errors.Is(err, io.EOF) {
+		t.Errorf("Map.Decode() error = %v, want io.EOF", err)
+	}
+}
+
+func TestCodec_DecodeDuplicateKey(t *testing.T) {
+	b := Wrap([]byte{2, 1, 10, 1, 20})
+	if v, err := Map(Uint8, Uint8, Uint8).Decode(b); err == nil {
+		t.Errorf("Map.Decode() = %v, want an error", v)
+	}
+}
+
+func TestCodec_DecodeTruncated(t *testing.T) {
+	b := NewBuffer()
+	_ = testParamCodec.Encode(b, testParam{ID: 1, Name: "test"})
+
+	data := b.Bytes()
+	for n := 0; n < len(data); n++ {
+		if _, err := testParamCodec.Decode(Wrap(data[:n])); err == nil {
+			t.Errorf("Decode() of %d bytes did not fail", n)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package jagbuf
+
+import "strings"
+
+// cp1252 maps the bytes 0x80 to 0x9F, where Windows-1252 differs from
+// Latin-1. The 5 unassigned bytes map to the control characters of the same
+// value so every byte round trips.
+var cp1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '\u008D', 'Ž', '\u008F',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '\u009D', 'ž', 'Ÿ',
+}
+
+// DecodeCP1252 converts Windows-1252 encoded bytes, the charset used by
+// Jagex for strings, to a UTF-8 string.
+func DecodeCP1252(data []byte) string {
+	builder := strings.Builder{}
+	builder.Grow(len(data))
+
+	for _, c := range data {
+		if c >= 0x80 && c < 0xA0 {
+			builder.WriteRune(cp1252[c-0x80])
+		} else {
+			builder.WriteRune(rune(c))
+		}
+	}
+
+	return builder.String()
+}
+
+// EncodeCP1252 converts a UTF-8 string to Windows-1252 encoded bytes.
+// Characters that have no Windows-1252 encoding are replaced with '?'.
+func EncodeCP1252(s string) []byte {
+	data := make([]byte, 0, len(s))
+
+	for _, r := range s {
+		data = append(data, encodeCP1252Rune(r))
+	}
+
+	return data
+}
+
+func encodeCP1252Rune(r rune) byte {
+	if r < 0x80 || (r >= 0xA0 && r <= 0xFF) {
+		return byte(r)
+	}
+
+	for i, c := range cp1252 {
+		if c == r {
+			return byte(0x80 + i)
+		}
+	}
+
+	return '?'
+}
@@ -0,0 +1,393 @@
+package jagbuf
+
+// Decoder wraps a Reader so that reads return plain values. The first
+// error is recorded and returned by Err, and every read after it returns
+// the zero value without touching the Reader. This allows a packet to be
+// decoded field by field with a single error check at the end:
+//
+//	d := jagbuf.NewDecoder(buffer)
+//	x := d.ReadUint16()
+//	y := d.ReadUint16LE_Sub()
+//	name := d.ReadString()
+//	if err := d.Err(); err != nil {
+//		return err
+//	}
+type Decoder struct {
+	r   Reader
+	err error
+}
+
+// NewDecoder creates a Decoder reading from r.
+func NewDecoder(r Reader) *Decoder {
+	return &Decoder{r: r}
+}
+
+// Err returns the first error encountered by the Decoder, or nil if every
+// read so far has succeeded.
+func (d *Decoder) Err() error {
+	return d.err
+}
+
+// stick calls read unless the Decoder has already failed, recording its
+// error.
+func stick[T any](d *Decoder, read func() (T, error)) T {
+	var val T
+	if d.err != nil {
+		return val
+	}
+
+	val, d.err = read()
+	return val
+}
+
+func (d *Decoder) ReadUint8() uint8 {
+	return stick(d, d.r.ReadUint8)
+}
+
+func (d *Decoder) ReadInt8() int8 {
+	return stick(d, d.r.ReadInt8)
+}
+
+func (d *Decoder) ReadUint8_Sub() uint8 {
+	return stick(d, d.r.ReadUint8_Sub)
+}
+
+func (d *Decoder) ReadUint8_Neg() uint8 {
+	return stick(d, d.r.ReadUint8_Neg)
+}
+
+func (d *Decoder) ReadUint8_Mirror() uint8 {
+	return stick(d, d.r.ReadUint8_Mirror)
+}
+
+func (d *Decoder) ReadInt8_Sub() int8 {
+	return stick(d, d.r.ReadInt8_Sub)
+}
+
+func (d *Decoder) ReadInt8_Neg() int8 {
+	return stick(d, d.r.ReadInt8_Neg)
+}
+
+func (d *Decoder) ReadInt8_Mirror() int8 {
+	return stick(d, d.r.ReadInt8_Mirror)
+}
+
+func (d *Decoder) ReadUint16() uint16 {
+	return stick(d, d.r.ReadUint16)
+}
+
+func (d *Decoder) ReadUint16_Sub() uint16 {
+	return stick(d, d.r.ReadUint16_Sub)
+}
+
+func (d *Decoder) ReadUint16LE() uint16 {
+	return stick(d, d.r.ReadUint16LE)
+}
+
+func (d *Decoder) ReadUint16LE_Sub() uint16 {
+	return stick(d, d.r.ReadUint16LE_Sub)
+}
+
+func (d *Decoder) ReadInt16() int16 {
+	return stick(d, d.r.ReadInt16)
+}
+
+func (d *Decoder) ReadInt16LE() int16 {
+	return stick(d, d.r.ReadInt16LE)
+}
+
+func (d *Decoder) ReadUint24() uint32 {
+	return stick(d, d.r.ReadUint24)
+}
+
+func (d *Decoder) ReadUint24LE() uint32 {
+	return stick(d, d.r.ReadUint24LE)
+}
+
+func (d *Decoder) ReadInt24() int32 {
+	return stick(d, d.r.ReadInt24)
+}
+
+func (d *Decoder) ReadInt24LE() int32 {
+	return stick(d, d.r.ReadInt24LE)
+}
+
+func (d *Decoder) ReadUint32() uint32 {
+	return stick(d, d.r.ReadUint32)
+}
+
+func (d *Decoder) ReadUint32LE() uint32 {
+	return stick(d, d.r.ReadUint32LE)
+}
+
+func (d *Decoder) ReadUint32V1() uint32 {
+	return stick(d, d.r.ReadUint32V1)
+}
+
+func (d *Decoder) ReadUint32V2() uint32 {
+	return stick(d, d.r.ReadUint32V2)
+}
+
+func (d *Decoder) ReadInt32() int32 {
+	return stick(d, d.r.ReadInt32)
+}
+
+func (d *Decoder) ReadInt32LE() int32 {
+	return stick(d, d.r.ReadInt32LE)
+}
+
+func (d *Decoder) ReadInt32V1() int32 {
+	return stick(d, d.r.ReadInt32V1)
+}
+
+func (d *Decoder) ReadInt32V2() int32 {
+	return stick(d, d.r.ReadInt32V2)
+}
+
+func (d *Decoder) ReadUint40() uint64 {
+	return stick(d, d.r.ReadUint40)
+}
+
+func (d *Decoder) ReadUint40LE() uint64 {
+	return stick(d, d.r.ReadUint40LE)
+}
+
+func (d *Decoder) ReadInt40() int64 {
+	return stick(d, d.r.ReadInt40)
+}
+
+func (d *Decoder) ReadInt40LE() int64 {
+	return stick(d, d.r.ReadInt40LE)
+}
+
+func (d *Decoder) ReadUint48() uint64 {
+	return stick(d, d.r.ReadUint48)
+}
+
+func (d *Decoder) ReadUint48LE() uint64 {
+	return stick(d, d.r.ReadUint48LE)
+}
+
+func (d *Decoder) ReadInt48() int64 {
+	return stick(d, d.r.ReadInt48)
+}
+
+func (d *Decoder) ReadInt48LE() int64 {
+	return stick(d, d.r.ReadInt48LE)
+}
+
+func (d *Decoder) ReadUint56() uint64 {
+	return stick(d, d.r.ReadUint56)
+}
+
+func (d *Decoder) ReadUint56LE() uint64 {
+	return stick(d, d.r.ReadUint56LE)
+}
+
+func (d *Decoder) ReadInt56() int64 {
+	return stick(d, d.r.ReadInt56)
+}
+
+func (d *Decoder) ReadInt56LE() int64 {
+	return stick(d, d.r.ReadInt56LE)
+}
+
+func (d *Decoder) ReadUint64() uint64 {
+	return stick(d, d.r.ReadUint64)
+}
+
+func (d *Decoder) ReadUint64LE() uint64 {
+	return stick(d, d.r.ReadUint64LE)
+}
+
+func (d *Decoder) ReadInt64() int64 {
+	return stick(d, d.r.ReadInt64)
+}
+
+func (d *Decoder) ReadInt64LE() int64 {
+	return stick(d, d.r.ReadInt64LE)
+}
+
+func (d *Decoder) ReadFloat32() float32 {
+	return stick(d, d.r.ReadFloat32)
+}
+
+func (d *Decoder) ReadFloat32LE() float32 {
+	return stick(d, d.r.ReadFloat32LE)
+}
+
+func (d *Decoder) ReadFloat64() float64 {
+	return stick(d, d.r.ReadFloat64)
+}
+
+func (d *Decoder) ReadFloat64LE() float64 {
+	return stick(d, d.r.ReadFloat64LE)
+}
+
+func (d *Decoder) ReadBool() bool {
+	return stick(d, d.r.ReadBool)
+}
+
+func (d *Decoder) ReadBoolStrict() bool {
+	return stick(d, d.r.ReadBoolStrict)
+}
+
+func (d *Decoder) ReadSmart() uint16 {
+	return stick(d, d.r.ReadSmart)
+}
+
+func (d *Decoder) ReadSignedSmart() int16 {
+	return stick(d, d.r.ReadSignedSmart)
+}
+
+func (d *Decoder) ReadVarInt() uint64 {
+	return stick(d, d.r.ReadVarInt)
+}
+
+func (d *Decoder) ReadSignedVarInt() int64 {
+	return stick(d, d.r.ReadSignedVarInt)
+}
+
+func (d *Decoder) ReadUintN(n int, o Order, t Transform) uint64 {
+	if d.err != nil {
+		return 0
+	}
+
+	val, err := d.r.ReadUintN(n, o, t)
+	d.err = err
+	return val
+}
+
+func (d *Decoder) ReadIntN(n int, o Order, t Transform) int64 {
+	if d.err != nil {
+		return 0
+	}
+
+	val, err := d.r.ReadIntN(n, o, t)
+	d.err = err
+	return val
+}
+
+func (d *Decoder) ReadUint8s(dst []uint8, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadUint8s(dst, t)
+	}
+}
+
+func (d *Decoder) ReadInt8s(dst []int8, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadInt8s(dst, t)
+	}
+}
+
+func (d *Decoder) ReadUint16s(dst []uint16, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadUint16s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadInt16s(dst []int16, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadInt16s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadUint24s(dst []uint32, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadUint24s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadInt24s(dst []int32, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadInt24s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadUint32s(dst []uint32, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadUint32s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadInt32s(dst []int32, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadInt32s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadUint64s(dst []uint64, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadUint64s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadInt64s(dst []int64, o Order, t Transform) {
+	if d.err == nil {
+		d.err = d.r.ReadInt64s(dst, o, t)
+	}
+}
+
+func (d *Decoder) ReadBytes(dst []byte) {
+	if d.err == nil {
+		d.err = d.r.ReadBytes(dst)
+	}
+}
+
+func (d *Decoder) ReadBytesAdd(dst []byte) {
+	if d.err == nil {
+		d.err = d.r.ReadBytesAdd(dst)
+	}
+}
+
+func (d *Decoder) ReadBytesReversed(dst []byte) {
+	if d.err == nil {
+		d.err = d.r.ReadBytesReversed(dst)
+	}
+}
+
+func (d *Decoder) ReadBytesReversedAdd(dst []byte) {
+	if d.err == nil {
+		d.err = d.r.ReadBytesReversedAdd(dst)
+	}
+}
+
+func (d *Decoder) ReadCount(p Prefix, max int) int {
+	return stick(d, func() (int, error) { return d.r.ReadCount(p, max) })
+}
+
+func (d *Decoder) ReadBytesPrefixed(p Prefix, max int) []byte {
+	return stick(d, func() ([]byte, error) { return d.r.ReadBytesPrefixed(p, max) })
+}
+
+func (d *Decoder) ReadString() string {
+	return stick(d, d.r.ReadString)
+}
+
+func (d *Decoder) ReadJagString() string {
+	return stick(d, d.r.ReadJagString)
+}
+
+func (d *Decoder) ReadVersionedString(version uint8) string {
+	return stick(d, func() (string, error) { return d.r.ReadVersionedString(version) })
+}
+
+func (d *Decoder) ReadNullableString() *string {
+	return stick(d, d.r.ReadNullableString)
+}
+
+func (d *Decoder) ReadCESU8String() string {
+	return stick(d, d.r.ReadCESU8String)
+}
+
+func (d *Decoder) ReadChar() uint16 {
+	return stick(d, d.r.ReadChar)
+}
+
+func (d *Decoder) ReadUTF() string {
+	return stick(d, d.r.ReadUTF)
+}
+
+func (d *Decoder) ReadBase37() string {
+	return stick(d, d.r.ReadBase37)
+}
@@ -0,0 +1,45 @@
+package jagbuf
+
+import "math"
+
+// ReadFloat32 reads an IEEE 754 single precision float in big endian order.
+func (b *Buffer) ReadFloat32() (float32, error) {
+	val, err := b.ReadUint32()
+	return math.Float32frombits(val), err
+}
+
+// ReadFloat32LE reads an IEEE 754 single precision float in little endian
+// order.
+func (b *Buffer) ReadFloat32LE() (float32, error) {
+	val, err := b.ReadUint32LE()
+	return math.Float32frombits(val), err
+}
+
+// ReadFloat64 reads an IEEE 754 double precision float in big endian order.
+func (b *Buffer) ReadFloat64() (float64, error) {
+	val, err := b.ReadUint64()
+	return math.Float64frombits(val), err
+}
+
+// ReadFloat64LE reads an IEEE 754 double precision float in little endian
+// order.
+func (b *Buffer) ReadFloat64LE() (float64, error) {
+	val, err := b.ReadUint64LE()
+	return math.Float64frombits(val), err
+}
+
+func (b *Buffer) WriteFloat32(v float32) {
+	b.WriteUint32(math.Float32bits(v))
+}
+
+func (b *Buffer) WriteFloat32LE(v float32) {
+	b.WriteUint32LE(math.Float32bits(v))
+}
+
+func (b *Buffer) WriteFloat64(v float64) {
+	b.WriteUint64(math.Float64bits(v))
+}
+
+func (b *Buffer) WriteFloat64LE(v float64) {
+	b.WriteUint64LE(math.Float64bits(v))
+}
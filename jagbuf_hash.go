@@ -0,0 +1,26 @@
+package jagbuf
+
+import "strings"
+
+// JagNameHash returns the hash used to identify files in the legacy .jag
+// archives of older revisions. Names are case insensitive.
+func JagNameHash(name string) int32 {
+	var h int32
+	for _, c := range strings.ToUpper(name) {
+		h = h*61 + c - 32
+	}
+	return h
+}
+
+// JS5NameHash returns the hash used to identify named groups and files in
+// JS5 caches, computed over the Windows-1252 encoding of name. The name is
+// hashed as given, callers looking up a name the way the client does should
+// lower case it first. Bytes are hashed as signed values, as they are in the
+// client.
+func JS5NameHash(name string) int32 {
+	var h int32
+	for _, c := range EncodeCP1252(name) {
+		h = h*31 + int32(int8(c))
+	}
+	return h
+}
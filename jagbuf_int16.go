@@ -1,16 +1,18 @@
 package jagbuf
 
-import "io"
+import (
+	"encoding/binary"
+	"io"
+)
 
 func (b *Buffer) ReadUint16() (uint16, error) {
 	if b.ReadableBytes() < 2 {
 		return 0, io.EOF
 	}
 
-	val := uint16(b.data[b.readIndex]) << 8
-	val |= uint16(b.data[b.readIndex+1])
+	val := binary.BigEndian.Uint16(b.data[b.readIndex : b.readIndex+2])
 
-	defer func() { b.readIndex += 2 }()
+	b.readIndex += 2
 	return val, nil
 }
 
@@ -21,10 +23,10 @@ func (b *Buffer) ReadUint16_Sub() (uint16, error) {
 		return 0, io.EOF
 	}
 
-	val := uint16(b.data[b.readIndex]) << 8
-	val |= uint16(b.data[b.readIndex+1]) - 128
+	s := b.data[b.readIndex : b.readIndex+2]
+	val := uint16(s[0])<<8 | uint16(s[1]-128)
 
-	defer func() { b.readIndex += 2 }()
+	b.readIndex += 2
 	return val, nil
 }
 
@@ -33,10 +35,9 @@ func (b *Buffer) ReadUint16LE() (uint16, error) {
 		return 0, io.EOF
 	}
 
-	val := uint16(b.data[b.readIndex])
-	val |= uint16(b.data[b.readIndex+1]) << 8
+	val := binary.LittleEndian.Uint16(b.data[b.readIndex : b.readIndex+2])
 
-	defer func() { b.readIndex += 2 }()
+	b.readIndex += 2
 	return val, nil
 }
 
@@ -47,10 +48,10 @@ func (b *Buffer) ReadUint16LE_Sub() (uint16, error) {
 		return 0, io.EOF
 	}
 
-	val := uint16(b.data[b.readIndex]) - 128
-	val |= uint16(b.data[b.readIndex+1]) << 8
+	s := b.data[b.readIndex : b.readIndex+2]
+	val := uint16(s[0]-128) | uint16(s[1])<<8
 
-	defer func() { b.readIndex += 2 }()
+	b.readIndex += 2
 	return val, nil
 }
 
@@ -67,10 +68,9 @@ func (b *Buffer) ReadInt16LE() (int16, error) {
 func (b *Buffer) WriteUint16(v uint16) {
 	b.ensureWritable(2)
 
-	b.data[b.writeIndex] = byte(v >> 8)
-	b.data[b.writeIndex+1] = byte(v & 0xFF)
+	binary.BigEndian.PutUint16(b.data[b.writeIndex:b.writeIndex+2], v)
 
-	defer func() { b.writeIndex += 2 }()
+	b.writeIndex += 2
 }
 
 func (b *Buffer) WriteInt16(v int16) {
@@ -80,10 +80,9 @@ func (b *Buffer) WriteInt16(v int16) {
 func (b *Buffer) WriteUint16LE(v uint16) {
 	b.ensureWritable(2)
 
-	b.data[b.writeIndex] = byte(v & 0xFF)
-	b.data[b.writeIndex+1] = byte(v >> 8)
+	binary.LittleEndian.PutUint16(b.data[b.writeIndex:b.writeIndex+2], v)
 
-	defer func() { b.writeIndex += 2 }()
+	b.writeIndex += 2
 }
 
 func (b *Buffer) WriteInt16LE(v int16) {
@@ -7,11 +7,10 @@ func (b *Buffer) ReadUint24() (uint32, error) {
 		return 0, io.EOF
 	}
 
-	val := uint32(b.data[b.readIndex]) << 16
-	val |= uint32(b.data[b.readIndex+1]) << 8
-	val |= uint32(b.data[b.readIndex+2])
+	s := b.data[b.readIndex : b.readIndex+3]
+	val := uint32(s[2]) | uint32(s[1])<<8 | uint32(s[0])<<16
 
-	defer func() { b.readIndex += 3 }()
+	b.readIndex += 3
 	return val, nil
 }
 
@@ -20,60 +19,36 @@ func (b *Buffer) ReadUint24LE() (uint32, error) {
 		return 0, io.EOF
 	}
 
-	val := uint32(b.data[b.readIndex])
-	val |= uint32(b.data[b.readIndex+1]) << 8
-	val |= uint32(b.data[b.readIndex+2]) << 16
+	s := b.data[b.readIndex : b.readIndex+3]
+	val := uint32(s[0]) | uint32(s[1])<<8 | uint32(s[2])<<16
 
-	defer func() { b.readIndex += 3 }()
+	b.readIndex += 3
 	return val, nil
 }
 
 func (b *Buffer) ReadInt24() (int32, error) {
-	if b.ReadableBytes() < 3 {
-		return 0, io.EOF
-	}
+	val, err := b.ReadUint24()
 
-	var val int32 = 0
-	if (b.data[b.readIndex] & 0x80) != 0 {
-		//sign extend if negative
-		val = -8388608
-	}
-
-	val |= int32(b.data[b.readIndex]) << 16
-	val |= int32(b.data[b.readIndex+1]) << 8
-	val |= int32(b.data[b.readIndex+2])
-
-	defer func() { b.readIndex += 3 }()
-	return val, nil
+	// sign extend from bit 23
+	return int32(val<<8) >> 8, err
 }
 
 func (b *Buffer) ReadInt24LE() (int32, error) {
-	if b.ReadableBytes() < 3 {
-		return 0, io.EOF
-	}
+	val, err := b.ReadUint24LE()
 
-	var val int32 = 0
-	if (b.data[b.readIndex+2] & 0x80) != 0 {
-		//sign extend if negative
-		val = -8388608
-	}
-
-	val |= int32(b.data[b.readIndex+2]) << 16
-	val |= int32(b.data[b.readIndex+1]) << 8
-	val |= int32(b.data[b.readIndex])
-
-	defer func() { b.readIndex += 3 }()
-	return val, nil
+	// sign extend from bit 23
+	return int32(val<<8) >> 8, err
 }
 
 func (b *Buffer) WriteUint24(v uint32) {
 	b.ensureWritable(3)
 
-	b.data[b.writeIndex] = byte(v >> 16)
-	b.data[b.writeIndex+1] = byte(v >> 8)
-	b.data[b.writeIndex+2] = byte(v & 0xFF)
+	s := b.data[b.writeIndex : b.writeIndex+3]
+	s[0] = byte(v >> 16)
+	s[1] = byte(v >> 8)
+	s[2] = byte(v)
 
-	defer func() { b.writeIndex += 3 }()
+	b.writeIndex += 3
 }
 
 func (b *Buffer) WriteInt24(v int32) {
@@ -83,11 +58,12 @@ func (b *Buffer) WriteInt24(v int32) {
 func (b *Buffer) WriteUint24LE(v uint32) {
 	b.ensureWritable(3)
 
-	b.data[b.writeIndex] = byte(v & 0xFF)
-	b.data[b.writeIndex+1] = byte(v >> 8)
-	b.data[b.writeIndex+2] = byte(v >> 16)
+	s := b.data[b.writeIndex : b.writeIndex+3]
+	s[0] = byte(v)
+	s[1] = byte(v >> 8)
+	s[2] = byte(v >> 16)
 
-	defer func() { b.writeIndex += 3 }()
+	b.writeIndex += 3
 }
 
 func (b *Buffer) WriteInt24LE(v int32) {
@@ -1,18 +1,18 @@
 package jagbuf
 
-import "io"
+import (
+	"encoding/binary"
+	"io"
+)
 
 func (b *Buffer) ReadUint32() (uint32, error) {
 	if b.ReadableBytes() < 4 {
 		return 0, io.EOF
 	}
 
-	val := uint32(b.data[b.readIndex]) << 24
-	val |= uint32(b.data[b.readIndex+1]) << 16
-	val |= uint32(b.data[b.readIndex+2]) << 8
-	val |= uint32(b.data[b.readIndex+3])
+	val := binary.BigEndian.Uint32(b.data[b.readIndex : b.readIndex+4])
 
-	defer func() { b.readIndex += 4 }()
+	b.readIndex += 4
 	return val, nil
 }
 
@@ -21,12 +21,9 @@ func (b *Buffer) ReadUint32LE() (uint32, error) {
 		return 0, io.EOF
 	}
 
-	val := uint32(b.data[b.readIndex])
-	val |= uint32(b.data[b.readIndex+1]) << 8
-	val |= uint32(b.data[b.readIndex+2]) << 16
-	val |= uint32(b.data[b.readIndex+3]) << 24
+	val := binary.LittleEndian.Uint32(b.data[b.readIndex : b.readIndex+4])
 
-	defer func() { b.readIndex += 4 }()
+	b.readIndex += 4
 	return val, nil
 }
 
@@ -37,12 +34,10 @@ func (b *Buffer) ReadUint32V1() (uint32, error) {
 		return 0, io.EOF
 	}
 
-	val := uint32(b.data[b.readIndex]) << 8
-	val |= uint32(b.data[b.readIndex+1])
-	val |= uint32(b.data[b.readIndex+2]) << 24
-	val |= uint32(b.data[b.readIndex+3]) << 16
+	s := b.data[b.readIndex : b.readIndex+4]
+	val := uint32(s[1]) | uint32(s[0])<<8 | uint32(s[3])<<16 | uint32(s[2])<<24
 
-	defer func() { b.readIndex += 4 }()
+	b.readIndex += 4
 	return val, nil
 }
 
@@ -53,12 +48,10 @@ func (b *Buffer) ReadUint32V2() (uint32, error) {
 		return 0, io.EOF
 	}
 
-	val := uint32(b.data[b.readIndex]) << 16
-	val |= uint32(b.data[b.readIndex+1]) << 24
-	val |= uint32(b.data[b.readIndex+2])
-	val |= uint32(b.data[b.readIndex+3]) << 8
+	s := b.data[b.readIndex : b.readIndex+4]
+	val := uint32(s[2]) | uint32(s[3])<<8 | uint32(s[0])<<16 | uint32(s[1])<<24
 
-	defer func() { b.readIndex += 4 }()
+	b.readIndex += 4
 	return val, nil
 }
 
@@ -89,12 +82,9 @@ func (b *Buffer) ReadInt32V2() (int32, error) {
 func (b *Buffer) WriteUint32(v uint32) {
 	b.ensureWritable(4)
 
-	b.data[b.writeIndex] = byte(v >> 24)
-	b.data[b.writeIndex+1] = byte(v >> 16)
-	b.data[b.writeIndex+2] = byte(v >> 8)
-	b.data[b.writeIndex+3] = byte(v & 0xFF)
+	binary.BigEndian.PutUint32(b.data[b.writeIndex:b.writeIndex+4], v)
 
-	defer func() { b.writeIndex += 4 }()
+	b.writeIndex += 4
 }
 
 func (b *Buffer) WriteInt32(v int32) {
@@ -104,12 +94,9 @@ func (b *Buffer) WriteInt32(v int32) {
 func (b *Buffer) WriteUint32LE(v uint32) {
 	b.ensureWritable(4)
 
-	b.data[b.writeIndex] = byte(v & 0xFF)
-	b.data[b.writeIndex+1] = byte(v >> 8)
-	b.data[b.writeIndex+2] = byte(v >> 16)
-	b.data[b.writeIndex+3] = byte(v >> 24)
+	binary.LittleEndian.PutUint32(b.data[b.writeIndex:b.writeIndex+4], v)
 
-	defer func() { b.writeIndex += 4 }()
+	b.writeIndex += 4
 }
 
 func (b *Buffer) WriteInt32LE(v int32) {
@@ -122,12 +109,13 @@ func (b *Buffer) WriteInt32LE(v int32) {
 func (b *Buffer) WriteUint32V1(v uint32) {
 	b.ensureWritable(4)
 
-	b.data[b.writeIndex] = byte(v >> 8)
-	b.data[b.writeIndex+1] = byte(v & 0xFF)
-	b.data[b.writeIndex+2] = byte(v >> 24)
-	b.data[b.writeIndex+3] = byte(v >> 16)
+	s := b.data[b.writeIndex : b.writeIndex+4]
+	s[0] = byte(v >> 8)
+	s[1] = byte(v)
+	s[2] = byte(v >> 24)
+	s[3] = byte(v >> 16)
 
-	defer func() { b.writeIndex += 4 }()
+	b.writeIndex += 4
 }
 
 // WriteInt32V1 writes an int32 to the buffer using a special Jagex
@@ -143,12 +131,13 @@ func (b *Buffer) WriteInt32V1(v int32) {
 func (b *Buffer) WriteUint32V2(v uint32) {
 	b.ensureWritable(4)
 
-	b.data[b.writeIndex] = byte(v >> 16)
-	b.data[b.writeIndex+1] = byte(v >> 24)
-	b.data[b.writeIndex+2] = byte(v & 0xFF)
-	b.data[b.writeIndex+3] = byte(v >> 8)
+	s := b.data[b.writeIndex : b.writeIndex+4]
+	s[0] = byte(v >> 16)
+	s[1] = byte(v >> 24)
+	s[2] = byte(v)
+	s[3] = byte(v >> 8)
 
-	defer func() { b.writeIndex += 4 }()
+	b.writeIndex += 4
 }
 
 // WriteInt32V2 writes an int32 to the buffer using a special Jagex
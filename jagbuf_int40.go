@@ -0,0 +1,75 @@
+package jagbuf
+
+import "io"
+
+func (b *Buffer) ReadUint40() (uint64, error) {
+	if b.ReadableBytes() < 5 {
+		return 0, io.EOF
+	}
+
+	s := b.data[b.readIndex : b.readIndex+5]
+	val := uint64(s[4]) | uint64(s[3])<<8 | uint64(s[2])<<16 | uint64(s[1])<<24 | uint64(s[0])<<32
+
+	b.readIndex += 5
+	return val, nil
+}
+
+func (b *Buffer) ReadUint40LE() (uint64, error) {
+	if b.ReadableBytes() < 5 {
+		return 0, io.EOF
+	}
+
+	s := b.data[b.readIndex : b.readIndex+5]
+	val := uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 | uint64(s[4])<<32
+
+	b.readIndex += 5
+	return val, nil
+}
+
+func (b *Buffer) ReadInt40() (int64, error) {
+	val, err := b.ReadUint40()
+
+	// sign extend from bit 39
+	return int64(val<<24) >> 24, err
+}
+
+func (b *Buffer) ReadInt40LE() (int64, error) {
+	val, err := b.ReadUint40LE()
+
+	// sign extend from bit 39
+	return int64(val<<24) >> 24, err
+}
+
+func (b *Buffer) WriteUint40(v uint64) {
+	b.ensureWritable(5)
+
+	s := b.data[b.writeIndex : b.writeIndex+5]
+	s[0] = byte(v >> 32)
+	s[1] = byte(v >> 24)
+	s[2] = byte(v >> 16)
+	s[3] = byte(v >> 8)
+	s[4] = byte(v)
+
+	b.writeIndex += 5
+}
+
+func (b *Buffer) WriteInt40(v int64) {
+	b.WriteUint40(uint64(v))
+}
+
+func (b *Buffer) WriteUint40LE(v uint64) {
+	b.ensureWritable(5)
+
+	s := b.data[b.writeIndex : b.writeIndex+5]
+	s[0] = byte(v)
+	s[1] = byte(v >> 8)
+	s[2] = byte(v >> 16)
+	s[3] = byte(v >> 24)
+	s[4] = byte(v >> 32)
+
+	b.writeIndex += 5
+}
+
+func (b *Buffer) WriteInt40LE(v int64) {
+	b.WriteUint40LE(uint64(v))
+}
@@ -0,0 +1,77 @@
+package jagbuf
+
+import "io"
+
+func (b *Buffer) ReadUint48() (uint64, error) {
+	if b.ReadableBytes() < 6 {
+		return 0, io.EOF
+	}
+
+	s := b.data[b.readIndex : b.readIndex+6]
+	val := uint64(s[5]) | uint64(s[4])<<8 | uint64(s[3])<<16 | uint64(s[2])<<24 | uint64(s[1])<<32 | uint64(s[0])<<40
+
+	b.readIndex += 6
+	return val, nil
+}
+
+func (b *Buffer) ReadUint48LE() (uint64, error) {
+	if b.ReadableBytes() < 6 {
+		return 0, io.EOF
+	}
+
+	s := b.data[b.readIndex : b.readIndex+6]
+	val := uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 | uint64(s[4])<<32 | uint64(s[5])<<40
+
+	b.readIndex += 6
+	return val, nil
+}
+
+func (b *Buffer) ReadInt48() (int64, error) {
+	val, err := b.ReadUint48()
+
+	// sign extend from bit 47
+	return int64(val<<16) >> 16, err
+}
+
+func (b *Buffer) ReadInt48LE() (int64, error) {
+	val, err := b.ReadUint48LE()
+
+	// sign extend from bit 47
+	return int64(val<<16) >> 16, err
+}
+
+func (b *Buffer) WriteUint48(v uint64) {
+	b.ensureWritable(6)
+
+	s := b.data[b.writeIndex : b.writeIndex+6]
+	s[0] = byte(v >> 40)
+	s[1] = byte(v >> 32)
+	s[2] = byte(v >> 24)
+	s[3] = byte(v >> 16)
+	s[4] = byte(v >> 8)
+	s[5] = byte(v)
+
+	b.writeIndex += 6
+}
+
+func (b *Buffer) WriteInt48(v int64) {
+	b.WriteUint48(uint64(v))
+}
+
+func (b *Buffer) WriteUint48LE(v uint64) {
+	b.ensureWritable(6)
+
+	s := b.data[b.writeIndex : b.writeIndex+6]
+	s[0] = byte(v)
+	s[1] = byte(v >> 8)
+	s[2] = byte(v >> 16)
+	s[3] = byte(v >> 24)
+	s[4] = byte(v >> 32)
+	s[5] = byte(v >> 40)
+
+	b.writeIndex += 6
+}
+
+func (b *Buffer) WriteInt48LE(v int64) {
+	b.WriteUint48LE(uint64(v))
+}
@@ -0,0 +1,79 @@
+package jagbuf
+
+import "io"
+
+func (b *Buffer) ReadUint56() (uint64, error) {
+	if b.ReadableBytes() < 7 {
+		return 0, io.EOF
+	}
+
+	s := b.data[b.readIndex : b.readIndex+7]
+	val := uint64(s[6]) | uint64(s[5])<<8 | uint64(s[4])<<16 | uint64(s[3])<<24 | uint64(s[2])<<32 | uint64(s[1])<<40 | uint64(s[0])<<48
+
+	b.readIndex += 7
+	return val, nil
+}
+
+func (b *Buffer) ReadUint56LE() (uint64, error) {
+	if b.ReadableBytes() < 7 {
+		return 0, io.EOF
+	}
+
+	s := b.data[b.readIndex : b.readIndex+7]
+	val := uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 | uint64(s[4])<<32 | uint64(s[5])<<40 | uint64(s[6])<<48
+
+	b.readIndex += 7
+	return val, nil
+}
+
+func (b *Buffer) ReadInt56() (int64, error) {
+	val, err := b.ReadUint56()
+
+	// sign extend from bit 55
+	return int64(val<<8) >> 8, err
+}
+
+func (b *Buffer) ReadInt56LE() (int64, error) {
+	val, err := b.ReadUint56LE()
+
+	// sign extend from bit 55
+	return int64(val<<8) >> 8, err
+}
+
+func (b *Buffer) WriteUint56(v uint64) {
+	b.ensureWritable(7)
+
+	s := b.data[b.writeIndex : b.writeIndex+7]
+	s[0] = byte(v >> 48)
+	s[1] = byte(v >> 40)
+	s[2] = byte(v >> 32)
+	s[3] = byte(v >> 24)
+	s[4] = byte(v >> 16)
+	s[5] = byte(v >> 8)
+	s[6] = byte(v)
+
+	b.writeIndex += 7
+}
+
+func (b *Buffer) WriteInt56(v int64) {
+	b.WriteUint56(uint64(v))
+}
+
+func (b *Buffer) WriteUint56LE(v uint64) {
+	b.ensureWritable(7)
+
+	s := b.data[b.writeIndex : b.writeIndex+7]
+	s[0] = byte(v)
+	s[1] = byte(v >> 8)
+	s[2] = byte(v >> 16)
+	s[3] = byte(v >> 24)
+	s[4] = byte(v >> 32)
+	s[5] = byte(v >> 40)
+	s[6] = byte(v >> 48)
+
+	b.writeIndex += 7
+}
+
+func (b *Buffer) WriteInt56LE(v int64) {
+	b.WriteUint56LE(uint64(v))
+}
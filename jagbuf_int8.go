@@ -9,7 +9,7 @@ func (b *Buffer) ReadUint8() (uint8, error) {
 
 	val := b.data[b.readIndex]
 
-	defer func() { b.readIndex += 1 }()
+	b.readIndex++
 	return val, nil
 }
 
@@ -20,38 +20,32 @@ func (b *Buffer) ReadInt8() (int8, error) {
 
 // ReadUint8_Sub reads an uint8 from the buffer and applies the `value - 128` transform.
 func (b *Buffer) ReadUint8_Sub() (uint8, error) {
-	if b.ReadableBytes() < 1 {
-		return 0, io.EOF
+	val, err := b.ReadUint8()
+	if err != nil {
+		return 0, err
 	}
 
-	val := b.data[b.readIndex] - 128
-
-	defer func() { b.readIndex += 1 }()
-	return val, nil
+	return val - 128, nil
 }
 
 // ReadUint8_Neg reads an uint8 from the buffer and applies the `0 - value` transform.
 func (b *Buffer) ReadUint8_Neg() (uint8, error) {
-	if b.ReadableBytes() < 1 {
-		return 0, io.EOF
+	val, err := b.ReadUint8()
+	if err != nil {
+		return 0, err
 	}
 
-	val := 0 - b.data[b.readIndex]
-
-	defer func() { b.readIndex += 1 }()
-	return val, nil
+	return 0 - val, nil
 }
 
 // ReadUint8_Mirror reads an uint8 from the buffer and applies the `128 - value` transform.
 func (b *Buffer) ReadUint8_Mirror() (uint8, error) {
-	if b.ReadableBytes() < 1 {
-		return 0, io.EOF
+	val, err := b.ReadUint8()
+	if err != nil {
+		return 0, err
 	}
 
-	val := 128 - b.data[b.readIndex]
-
-	defer func() { b.readIndex += 1 }()
-	return val, nil
+	return 128 - val, nil
 }
 
 // ReadInt8_Sub reads an int8 from the buffer and applies the `value - 128` transform.
@@ -77,7 +71,7 @@ func (b *Buffer) WriteUint8(v uint8) {
 
 	b.data[b.writeIndex] = v
 
-	defer func() { b.writeIndex += 1 }()
+	b.writeIndex++
 }
 
 func (b *Buffer) WriteInt8(v int8) {
@@ -0,0 +1,166 @@
+package jagbuf
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"unicode/utf16"
+)
+
+// The methods in this file cover the parts of Java's DataInput and
+// DataOutput that have no equivalent elsewhere in the package, so data
+// written by a DataOutputStream can be read back with a Buffer. The rest map
+// directly onto existing methods:
+//
+//	readBoolean        ReadBool
+//	readByte           ReadInt8
+//	readUnsignedByte   ReadUint8
+//	readShort          ReadInt16
+//	readUnsignedShort  ReadUint16
+//	readInt            ReadInt32
+//	readLong           ReadInt64
+//	readFloat          ReadFloat32
+//	readDouble         ReadFloat64
+//	readFully          ReadBytes
+//	skipBytes          Skip
+
+// MaxUTFLen is the longest encoded string, in bytes, that can be written
+// with WriteUTF.
+const MaxUTFLen = math.MaxUint16
+
+// ErrInvalidUTF is returned when decoding malformed modified UTF-8.
+var ErrInvalidUTF = errors.New("jagbuf: malformed modified UTF-8")
+
+// ReadChar reads a Java char, a single UTF-16 code unit.
+func (b *Buffer) ReadChar() (uint16, error) {
+	return b.ReadUint16()
+}
+
+// WriteChar writes a Java char, a single UTF-16 code unit.
+func (b *Buffer) WriteChar(v uint16) {
+	b.WriteUint16(v)
+}
+
+// WriteChars writes each UTF-16 code unit of s as a char without a length
+// prefix, like DataOutput.writeChars.
+func (b *Buffer) WriteChars(s string) {
+	b.WriteUint16s(utf16.Encode([]rune(s)), BigEndian, TransformNone)
+}
+
+// ReadUTF reads a string in the format of DataInput.readUTF, a u16 byte
+// length followed by modified UTF-8. Malformed data fails with an error
+// wrapping ErrInvalidUTF. Nothing is consumed unless the string is valid.
+func (b *Buffer) ReadUTF() (string, error) {
+	n, err := b.PeekUint16()
+	if err != nil {
+		return "", err
+	}
+
+	if b.ReadableBytes() < 2+int(n) {
+		return "", io.EOF
+	}
+
+	start := b.readIndex + 2
+	s, err := decodeModifiedUTF8(b.data[start : start+int(n)])
+	if err != nil {
+		return "", err
+	}
+
+	b.readIndex = start + int(n)
+	return s, nil
+}
+
+// WriteUTF writes s in the format of DataOutput.writeUTF. It panics if the
+// encoded string is longer than MaxUTFLen bytes.
+func (b *Buffer) WriteUTF(s string) {
+	n := modifiedUTF8Len(s, true)
+	if n > MaxUTFLen {
+		panic(fmt.Sprintf("jagbuf: UTF string of %d bytes too long", n))
+	}
+
+	b.WriteUint16(uint16(n))
+	b.ensureWritable(n)
+	appendModifiedUTF8(b.data[b.writeIndex:b.writeIndex], s, true)
+	b.writeIndex += n
+}
+
+// modifiedUTF8Len returns the length of s encoded by appendModifiedUTF8.
+func modifiedUTF8Len(s string, java bool) int {
+	n := 0
+	for _, r := range s {
+		switch {
+		case r == 0 && java:
+			n += 2
+		case r < 0x80:
+			n++
+		case r < 0x800:
+			n += 2
+		case r < 0x10000:
+			n += 3
+		default:
+			n += 6
+		}
+	}
+	return n
+}
+
+// appendModifiedUTF8 appends s to dst as CESU-8, which differs from UTF-8 in
+// encoding characters outside the Basic Multilingual Plane as a surrogate
+// pair of 3 byte sequences. If java is set, 0 is also encoded as 2 bytes so
+// the output contains no 0 bytes, giving Java's modified UTF-8.
+func appendModifiedUTF8(dst []byte, s string, java bool) []byte {
+	for _, r := range s {
+		switch {
+		case r == 0 && java:
+			dst = append(dst, 0xC0, 0x80)
+		case r < 0x80:
+			dst = append(dst, byte(r))
+		case r < 0x800:
+			dst = append(dst, 0xC0|byte(r>>6), 0x80|byte(r)&0x3F)
+		case r < 0x10000:
+			dst = appendUTF8Unit(dst, uint16(r))
+		default:
+			r1, r2 := utf16.EncodeRune(r)
+			dst = appendUTF8Unit(dst, uint16(r1))
+			dst = appendUTF8Unit(dst, uint16(r2))
+		}
+	}
+	return dst
+}
+
+// appendUTF8Unit appends the 3 byte encoding of a UTF-16 code unit.
+func appendUTF8Unit(dst []byte, c uint16) []byte {
+	return append(dst, 0xE0|byte(c>>12), 0x80|byte(c>>6)&0x3F, 0x80|byte(c)&0x3F)
+}
+
+// decodeModifiedUTF8 decodes CESU-8 or Java's modified UTF-8, which only
+// differ in how 0 is encoded.
+func decodeModifiedUTF8(data []byte) (string, error) {
+	units := make([]uint16, 0, len(data))
+
+	for i := 0; i < len(data); {
+		c := data[i]
+		switch {
+		case c < 0x80:
+			units = append(units, uint16(c))
+			i++
+		case c&0xE0 == 0xC0:
+			if i+1 >= len(data) || data[i+1]&0xC0 != 0x80 {
+				return "", fmt.Errorf("%w: at byte %d", ErrInvalidUTF, i)
+			}
+			units = append(units, uint16(c&0x1F)<<6|uint16(data[i+1]&0x3F))
+			i += 2
+		case c&0xF0 == 0xE0:
+			if i+2 >= len(data) || data[i+1]&0xC0 != 0x80 || data[i+2]&0xC0 != 0x80 {
+				return "", fmt.Errorf("%w: at byte %d", ErrInvalidUTF, i)
+			}
+			units = append(units, uint16(c&0x0F)<<12|uint16(data[i+1]&0x3F)<<6|uint16(data[i+2]&0x3F))
+			i += 3
+		default:
+			return "", fmt.Errorf("%w: at byte %d", ErrInvalidUTF, i)
+		}
+	}
+
+	return string(utf16.Decode(units)), nil
+}
@@ -0,0 +1,359 @@
+package jagbuf
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+
+	"github.com/apogee-rs/jagbuf/internal/tag"
+	"github.com/apogee-rs/jagbuf/internal/wire"
+)
+
+// Marshal encodes the struct v into a new Buffer, field by field in
+// declaration order, as described by the `jag` tag on each field.
+//
+// A tag starts with the wire type of the field, followed by options:
+//
+//	u8, i8, u16, i16, u24, i24, u32, i32, u64, i64  fixed width integers
+//	smart, ssmart                                   unsigned and signed smarts
+//	string, jagstring                               0 terminated strings
+//	bytes                                           raw bytes
+//
+//	be, le, v1, v2       byte order of an integer (big endian by default)
+//	add, neg, mirror     transform applied to the lowest order byte
+//	cp1252               convert a string to and from Windows-1252
+//	len=<type>           count prefix for a slice, written as <type>
+//
+// Integer types may be set on any integer field, and an error is returned
+// for values that do not fit the wire type. A slice field must have a len
+// option and every element is encoded with the rest of the tag, except for
+// bytes which are read to the end of the buffer when no len is given.
+// Array fields are encoded element by element without a prefix. Struct
+// fields are encoded recursively and need no tag, and fields tagged with
+// `jag:"-"` and unexported fields are skipped.
+//
+//	type PlayerMove struct {
+//		X       uint16 `jag:"u16,le,add"`
+//		Y       uint16 `jag:"u16,le"`
+//		Flags   uint8  `jag:"u8,neg"`
+//		Path    []Step `jag:"len=u8"`
+//	}
+func Marshal(v any) (*Buffer, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jagbuf: cannot marshal %T, expected a struct", v)
+	}
+
+	buffer := NewBuffer()
+	if err := encodeStruct(buffer, rv); err != nil {
+		return nil, fmt.Errorf("jagbuf: marshal %v: %w", rv.Type(), err)
+	}
+
+	return buffer, nil
+}
+
+// Unmarshal decodes a struct encoded by Marshal from b into the struct
+// pointed to by v.
+func Unmarshal(b *Buffer, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("jagbuf: cannot unmarshal into %T, expected a non-nil struct pointer", v)
+	}
+
+	if err := decodeStruct(b, rv.Elem()); err != nil {
+		return fmt.Errorf("jagbuf: unmarshal %v: %w", rv.Elem().Type(), err)
+	}
+
+	return nil
+}
+
+// structField is a field of a struct type with its parsed tag.
+type structField struct {
+	index int
+	name  string
+	spec  *tag.Spec
+}
+
+var structFieldCache sync.Map // map[reflect.Type][]structField
+
+func structFields(t reflect.Type) ([]structField, error) {
+	if cached, ok := structFieldCache.Load(t); ok {
+		return cached.([]structField), nil
+	}
+
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		jagTag, tagged := f.Tag.Lookup("jag")
+		if !f.IsExported() || jagTag == "-" {
+			continue
+		}
+
+		spec, err := tag.Parse(jagTag)
+		if err == nil && spec.Kind == tag.Struct && !isStructType(f.Type) {
+			if !tagged {
+				err = errors.New("missing jag tag")
+			} else {
+				err = errors.New("missing wire type")
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		fields = append(fields, structField{index: i, name: f.Name, spec: spec})
+	}
+
+	cached, _ := structFieldCache.LoadOrStore(t, fields)
+	return cached.([]structField), nil
+}
+
+// isStructType reports whether t is a struct, or a slice or array of them.
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func encodeStruct(b *Buffer, v reflect.Value) error {
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if err := encodeValue(b, f.spec, v.Field(f.index)); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func decodeStruct(b *Buffer, v reflect.Value) error {
+	fields, err := structFields(v.Type())
+	if err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		if err := decodeValue(b, f.spec, v.Field(f.index)); err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+	}
+
+	return nil
+}
+
+func isByteSlice(v reflect.Value) bool {
+	return (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() == reflect.Uint8
+}
+
+func encodeValue(b *Buffer, spec *tag.Spec, v reflect.Value) error {
+	if spec.Kind == tag.Bytes {
+		if !isByteSlice(v) {
+			return fmt.Errorf("bytes cannot be stored in %v", v.Type())
+		}
+
+		if v.Kind() == reflect.Array {
+			if spec.Length != nil {
+				return errors.New("len is not valid for arrays")
+			}
+			for i := 0; i < v.Len(); i++ {
+				b.WriteUint8(uint8(v.Index(i).Uint()))
+			}
+			return nil
+		}
+
+		if spec.Length != nil {
+			if err := wire.WriteCount[Order, Transform](b, spec.Length, v.Len()); err != nil {
+				return err
+			}
+		}
+		b.Write(v.Bytes())
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if spec.Length == nil {
+			return errors.New("slices need a len option")
+		}
+		if err := wire.WriteCount[Order, Transform](b, spec.Length, v.Len()); err != nil {
+			return err
+		}
+		fallthrough
+	case reflect.Array:
+		if v.Kind() == reflect.Array && spec.Length != nil {
+			return errors.New("len is not valid for arrays")
+		}
+		elem := spec.Element()
+		for i := 0; i < v.Len(); i++ {
+			if err := encodeValue(b, elem, v.Index(i)); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	switch spec.Kind {
+	case tag.Int, tag.Smart:
+		bits, err := intBits(spec, v)
+		if err != nil {
+			return err
+		}
+		wire.WriteInt[Order, Transform](b, spec, bits)
+	case tag.String:
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("string cannot be stored in %v", v.Type())
+		}
+		if spec.Jag {
+			b.WriteUint8(0)
+		}
+		if spec.CP1252 {
+			b.Write(EncodeCP1252(v.String()))
+			b.WriteUint8(0)
+		} else {
+			b.WriteString(v.String())
+		}
+	case tag.Struct:
+		return encodeStruct(b, v)
+	}
+
+	return nil
+}
+
+func decodeValue(b *Buffer, spec *tag.Spec, v reflect.Value) error {
+	if spec.Kind == tag.Bytes {
+		if !isByteSlice(v) {
+			return fmt.Errorf("bytes cannot be stored in %v", v.Type())
+		}
+
+		if v.Kind() == reflect.Array {
+			if spec.Length != nil {
+				return errors.New("len is not valid for arrays")
+			}
+			for i := 0; i < v.Len(); i++ {
+				val, err := b.ReadUint8()
+				if err != nil {
+					return err
+				}
+				v.Index(i).SetUint(uint64(val))
+			}
+			return nil
+		}
+
+		count := b.ReadableBytes()
+		if spec.Length != nil {
+			var err error
+			if count, err = wire.ReadCount[Order, Transform](b, spec.Length); err != nil {
+				return err
+			}
+		}
+
+		data := make([]byte, count)
+		if err := b.ReadBytes(data); err != nil {
+			return err
+		}
+		v.SetBytes(data)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if spec.Length == nil {
+			return errors.New("slices need a len option")
+		}
+		count, err := wire.ReadCount[Order, Transform](b, spec.Length)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.MakeSlice(v.Type(), count, count))
+		fallthrough
+	case reflect.Array:
+		if v.Kind() == reflect.Array && spec.Length != nil {
+			return errors.New("len is not valid for arrays")
+		}
+		elem := spec.Element()
+		for i := 0; i < v.Len(); i++ {
+			if err := decodeValue(b, elem, v.Index(i)); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	switch spec.Kind {
+	case tag.Int, tag.Smart:
+		bits, err := wire.ReadInt[Order, Transform](b, spec)
+		if err != nil {
+			return err
+		}
+		return setIntBits(spec, v, bits)
+	case tag.String:
+		if v.Kind() != reflect.String {
+			return fmt.Errorf("string cannot be stored in %v", v.Type())
+		}
+		var str string
+		var err error
+		if spec.Jag {
+			str, err = b.ReadJagString()
+		} else {
+			str, err = b.ReadString()
+		}
+		if err != nil {
+			return err
+		}
+		if spec.CP1252 {
+			str = DecodeCP1252([]byte(str))
+		}
+		v.SetString(str)
+	case tag.Struct:
+		return decodeStruct(b, v)
+	}
+
+	return nil
+}
+
+// intBits returns the integer held by v after checking it fits the spec.
+func intBits(spec *tag.Spec, v reflect.Value) (uint64, error) {
+	switch {
+	case v.CanInt():
+		return wire.IntBits(spec, v.Int())
+	case v.CanUint():
+		return wire.UintBits(spec, v.Uint())
+	}
+
+	return 0, fmt.Errorf("integer cannot be stored in %v", v.Type())
+}
+
+// setIntBits sets v to an integer decoded with the spec, checking it fits.
+func setIntBits(spec *tag.Spec, v reflect.Value, bits uint64) error {
+	lo, _ := spec.Range()
+	negative := lo < 0 && int64(bits) < 0
+
+	switch {
+	case v.CanInt():
+		if !negative && bits > math.MaxInt64 || v.OverflowInt(int64(bits)) {
+			return fmt.Errorf("value %d overflows %v", bits, v.Type())
+		}
+		v.SetInt(int64(bits))
+	case v.CanUint():
+		if negative || v.OverflowUint(bits) {
+			return fmt.Errorf("value %d overflows %v", int64(bits), v.Type())
+		}
+		v.SetUint(bits)
+	default:
+		return fmt.Errorf("integer cannot be stored in %v", v.Type())
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package jagbuf
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apogee-rs/jagbuf/internal/tag"
+)
+
+type testStep struct {
+	DX int8 `jag:"i8"`
+	DY int8 `jag:"i8,neg"`
+}
+
+type testPacket struct {
+	X       uint16     `jag:"u16,le,add"`
+	Y       int        `jag:"u16"`
+	Plane   uint8      `jag:"u8,mirror"`
+	Seed    int32      `jag:"i32,v2"`
+	Count   int        `jag:"smart"`
+	Delta   int16      `jag:"ssmart"`
+	Name    string     `jag:"string,cp1252"`
+	Title   string     `jag:"jagstring"`
+	Path    []testStep `jag:"len=u8"`
+	Ids     []uint16   `jag:"u16,len=smart"`
+	Colours [2]int32   `jag:"i24"`
+	Payload []byte     `jag:"bytes,len=u8"`
+	Trailer []byte     `jag:"bytes"`
+	Cached  int        `jag:"-"`
+	local   int
+}
+
+func TestMarshal_RoundTrip(t *testing.T) {
+	packet := testPacket{
+		X:       3200,
+		Y:       3200,
+		Plane:   1,
+		Seed:    -12345678,
+		Count:   300,
+		Delta:   -100,
+		Name:    "Zezima €",
+		Title:   "the",
+		Path:    []testStep{{1, -1}, {-1, 1}},
+		Ids:     []uint16{1, 65535},
+		Colours: [2]int32{-1, 8388607},
+		Payload: []byte{1, 2, 3},
+		Trailer: []byte{4, 5},
+	}
+
+	buffer, err := Marshal(&packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded testPacket
+	if err := Unmarshal(buffer, &decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(packet, decoded) {
+		t.Errorf("Unmarshal fail: expected %+v, got %+v", packet, decoded)
+	}
+
+	if buffer.ReadableBytes() != 0 {
+		t.Errorf("Unmarshal fail: %d bytes left unread", buffer.ReadableBytes())
+	}
+}
+
+func TestMarshal_MatchesBufferMethods(t *testing.T) {
+	type move struct {
+		X    uint16 `jag:"u16,le,add"`
+		Y    uint16 `jag:"u16"`
+		Name string `jag:"string,cp1252"`
+	}
+
+	buffer, err := Marshal(move{X: 0x1020, Y: 0x3040, Name: "€"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []byte{0xA0, 0x10, 0x30, 0x40, 0x80, 0x00}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Fatalf("Marshal fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	x, _ := buffer.ReadUint16LE_Sub()
+	y, _ := buffer.ReadUint16()
+	if x != 0x1020 || y != 0x3040 {
+		t.Errorf("Marshal fail: read back 0x%x and 0x%x", x, y)
+	}
+}
+
+func TestMarshal_Errors(t *testing.T) {
+	type outOfRange struct {
+		V int `jag:"u8"`
+	}
+	if _, err := Marshal(outOfRange{V: 256}); err == nil || !strings.Contains(err.Error(), "out of range") {
+		t.Errorf("Marshal fail: expected an out of range error, got %v", err)
+	}
+
+	type untagged struct {
+		V int
+	}
+	if _, err := Marshal(untagged{}); err == nil || !strings.Contains(err.Error(), "missing jag tag") {
+		t.Errorf("Marshal fail: expected a missing tag error, got %v", err)
+	}
+
+	type badOrder struct {
+		V uint16 `jag:"u16,v1"`
+	}
+	if _, err := Marshal(badOrder{}); err == nil {
+		t.Error("Marshal fail: expected an error for v1 on a 16-bit integer")
+	}
+
+	type counted struct {
+		V []uint8 `jag:"u8,len=u32"`
+	}
+	huge := Wrap([]byte{0x7F, 0xFF, 0xFF, 0xFF, 0x00})
+	if err := Unmarshal(huge, &counted{}); !errors.Is(err, io.EOF) {
+		t.Errorf("Unmarshal fail: expected io.EOF for a count larger than the data, got %v", err)
+	}
+}
+
+func TestBuffer_ReadWriteSmart(t *testing.T) {
+	buffer := NewWithCapacity(64)
+
+	buffer.WriteSmart(127)
+	buffer.WriteSmart(128)
+	buffer.WriteSmart(MaxSmart)
+	buffer.WriteSignedSmart(-64)
+	buffer.WriteSignedSmart(MinSignedSmart)
+	buffer.WriteSignedSmart(MaxSignedSmart)
+
+	expected := []byte{0x7F, 0x80, 0x80, 0xFF, 0xFF, 0x00, 0x80, 0x00, 0xFF, 0xFF}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Fatalf("WriteSmart fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	for _, want := range []uint16{127, 128, MaxSmart} {
+		if val, err := buffer.ReadSmart(); err != nil || val != want {
+			t.Errorf("ReadSmart fail: expected %d, got %d (%v)", want, val, err)
+		}
+	}
+
+	for _, want := range []int16{-64, MinSignedSmart, MaxSignedSmart} {
+		if val, err := buffer.ReadSignedSmart(); err != nil || val != want {
+			t.Errorf("ReadSignedSmart fail: expected %d, got %d (%v)", want, val, err)
+		}
+	}
+}
+
+func TestBuffer_ReadSmart_Truncated(t *testing.T) {
+	if val, err := Wrap([]byte{0x80}).ReadSmart(); err != io.EOF || val != 0 {
+		t.Errorf("ReadSmart fail: expected 0 and io.EOF, got %d, %v", val, err)
+	}
+	if val, err := Wrap([]byte{0x80}).ReadSignedSmart(); err != io.EOF || val != 0 {
+		t.Errorf("ReadSignedSmart fail: expected 0 and io.EOF, got %d, %v", val, err)
+	}
+}
+
+func TestCP1252_RoundTrip(t *testing.T) {
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+
+	if !bytes.Equal(EncodeCP1252(DecodeCP1252(data)), data) {
+		t.Error("CP1252 fail: bytes did not round trip")
+	}
+
+	if encoded := EncodeCP1252("a€✓"); !bytes.Equal(encoded, []byte{'a', 0x80, '?'}) {
+		t.Errorf("EncodeCP1252 fail: got %v", encoded)
+	}
+}
+
+func TestMarshal_TagEnumsMatch(t *testing.T) {
+	if tag.MiddleEndianV2 != tag.Order(MiddleEndianV2) || tag.Mirror != tag.Transform(TransformMirror) {
+		t.Error("internal/tag Order and Transform values no longer match jagbuf")
+	}
+}
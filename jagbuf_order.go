@@ -0,0 +1,212 @@
+package jagbuf
+
+import (
+	"fmt"
+	"io"
+)
+
+// Order is the byte order of a multi-byte value.
+type Order int
+
+const (
+	BigEndian Order = iota
+	LittleEndian
+	// MiddleEndianV1 is big endian with the first 2 bytes shifted to the
+	// end, as used by ReadUint32V1. It only applies to 4 byte values.
+	MiddleEndianV1
+	// MiddleEndianV2 is little endian with the first 2 bytes shifted to the
+	// end, as used by ReadUint32V2. It only applies to 4 byte values.
+	MiddleEndianV2
+)
+
+// Transform is the transform Jagex applies to the lowest order byte of a
+// value. Each transform is named after how the value is written.
+type Transform int
+
+const (
+	TransformNone Transform = iota
+	// TransformAdd is written as `value + 128` and read back with
+	// `value - 128`, the transform applied by the _Sub read methods.
+	TransformAdd
+	// TransformNeg is written and read back with `0 - value`.
+	TransformNeg
+	// TransformMirror is written and read back with `128 - value`.
+	TransformMirror
+)
+
+func (o Order) String() string {
+	switch o {
+	case BigEndian:
+		return "BigEndian"
+	case LittleEndian:
+		return "LittleEndian"
+	case MiddleEndianV1:
+		return "MiddleEndianV1"
+	case MiddleEndianV2:
+		return "MiddleEndianV2"
+	}
+	return fmt.Sprintf("Order(%d)", int(o))
+}
+
+func (t Transform) String() string {
+	switch t {
+	case TransformNone:
+		return "TransformNone"
+	case TransformAdd:
+		return "TransformAdd"
+	case TransformNeg:
+		return "TransformNeg"
+	case TransformMirror:
+		return "TransformMirror"
+	}
+	return fmt.Sprintf("Transform(%d)", int(t))
+}
+
+// checkLayout panics if n bytes cannot be laid out in order o. An invalid
+// layout is a programming error, much like an out of range slice index.
+func checkLayout(n int, o Order, t Transform) {
+	if n < 1 || n > 8 {
+		panic(fmt.Sprintf("jagbuf: invalid integer width %d", n))
+	}
+
+	switch o {
+	case BigEndian, LittleEndian:
+	case MiddleEndianV1, MiddleEndianV2:
+		if n != 4 {
+			panic(fmt.Sprintf("jagbuf: %v requires a 4 byte integer, got %d", o, n))
+		}
+	default:
+		panic(fmt.Sprintf("jagbuf: invalid %v", o))
+	}
+
+	if t < TransformNone || t > TransformMirror {
+		panic(fmt.Sprintf("jagbuf: invalid %v", t))
+	}
+}
+
+// decode undoes the transform on the lowest order byte of v.
+func (t Transform) decode(v uint64) uint64 {
+	low := byte(v)
+	switch t {
+	case TransformAdd:
+		low -= 128
+	case TransformNeg:
+		low = 0 - low
+	case TransformMirror:
+		low = 128 - low
+	}
+	return v&^0xFF | uint64(low)
+}
+
+// encode applies the transform to the lowest order byte of v.
+func (t Transform) encode(v uint64) uint64 {
+	low := byte(v)
+	switch t {
+	case TransformAdd:
+		low += 128
+	case TransformNeg:
+		low = 0 - low
+	case TransformMirror:
+		low = 128 - low
+	}
+	return v&^0xFF | uint64(low)
+}
+
+// getUint decodes len(s) bytes in order o. The layout must have been
+// checked with checkLayout.
+func getUint(s []byte, o Order) uint64 {
+	var val uint64
+	switch o {
+	case BigEndian:
+		for _, v := range s {
+			val = val<<8 | uint64(v)
+		}
+	case LittleEndian:
+		for i := len(s) - 1; i >= 0; i-- {
+			val = val<<8 | uint64(s[i])
+		}
+	case MiddleEndianV1:
+		s = s[:4]
+		val = uint64(s[1]) | uint64(s[0])<<8 | uint64(s[3])<<16 | uint64(s[2])<<24
+	case MiddleEndianV2:
+		s = s[:4]
+		val = uint64(s[2]) | uint64(s[3])<<8 | uint64(s[0])<<16 | uint64(s[1])<<24
+	}
+	return val
+}
+
+// putUint encodes the low len(s) bytes of v in order o. The layout must have
+// been checked with checkLayout.
+func putUint(s []byte, v uint64, o Order) {
+	switch o {
+	case BigEndian:
+		for i := len(s) - 1; i >= 0; i-- {
+			s[i] = byte(v)
+			v >>= 8
+		}
+	case LittleEndian:
+		for i := range s {
+			s[i] = byte(v)
+			v >>= 8
+		}
+	case MiddleEndianV1:
+		s = s[:4]
+		s[0] = byte(v >> 8)
+		s[1] = byte(v)
+		s[2] = byte(v >> 24)
+		s[3] = byte(v >> 16)
+	case MiddleEndianV2:
+		s = s[:4]
+		s[0] = byte(v >> 16)
+		s[1] = byte(v >> 24)
+		s[2] = byte(v)
+		s[3] = byte(v >> 8)
+	}
+}
+
+// signExtend sign extends the low n bytes of v.
+func signExtend(v uint64, n int) int64 {
+	shift := 64 - 8*n
+	return int64(v<<shift) >> shift
+}
+
+// ReadUintN reads an unsigned integer n bytes wide (1 to 8) in order o,
+// undoing transform t on the lowest order byte. This is the general form of
+// the fixed width Read* methods, for layouts that have no dedicated method.
+func (b *Buffer) ReadUintN(n int, o Order, t Transform) (uint64, error) {
+	checkLayout(n, o, t)
+
+	if b.ReadableBytes() < n {
+		return 0, io.EOF
+	}
+
+	val := getUint(b.data[b.readIndex:b.readIndex+n], o)
+
+	b.readIndex += n
+	return t.decode(val), nil
+}
+
+// ReadIntN reads a signed integer n bytes wide (1 to 8) in order o, undoing
+// transform t on the lowest order byte and sign extending the result.
+func (b *Buffer) ReadIntN(n int, o Order, t Transform) (int64, error) {
+	val, err := b.ReadUintN(n, o, t)
+	return signExtend(val, n), err
+}
+
+// WriteUintN writes the low n bytes (1 to 8) of v in order o, applying
+// transform t to the lowest order byte.
+func (b *Buffer) WriteUintN(n int, v uint64, o Order, t Transform) {
+	checkLayout(n, o, t)
+
+	b.ensureWritable(n)
+
+	putUint(b.data[b.writeIndex:b.writeIndex+n], t.encode(v), o)
+
+	b.writeIndex += n
+}
+
+// WriteIntN writes the low n bytes (1 to 8) of v in order o, applying
+// transform t to the lowest order byte.
+func (b *Buffer) WriteIntN(n int, v int64, o Order, t Transform) {
+	b.WriteUintN(n, uint64(v), o, t)
+}
@@ -0,0 +1,268 @@
+package jagbuf
+
+// peek decodes a value with read and then restores the read index, so the
+// value is left in the buffer.
+func peek[T any](b *Buffer, read func(*Buffer) (T, error)) (T, error) {
+	readIndex := b.readIndex
+	val, err := read(b)
+	b.readIndex = readIndex
+	return val, err
+}
+
+// Each Peek* method decodes the same value as the Read* method of the same
+// name, without advancing the read index.
+
+func (b *Buffer) PeekUint8() (uint8, error) {
+	return peek(b, (*Buffer).ReadUint8)
+}
+
+func (b *Buffer) PeekInt8() (int8, error) {
+	return peek(b, (*Buffer).ReadInt8)
+}
+
+func (b *Buffer) PeekUint8_Sub() (uint8, error) {
+	return peek(b, (*Buffer).ReadUint8_Sub)
+}
+
+func (b *Buffer) PeekUint8_Neg() (uint8, error) {
+	return peek(b, (*Buffer).ReadUint8_Neg)
+}
+
+func (b *Buffer) PeekUint8_Mirror() (uint8, error) {
+	return peek(b, (*Buffer).ReadUint8_Mirror)
+}
+
+func (b *Buffer) PeekInt8_Sub() (int8, error) {
+	return peek(b, (*Buffer).ReadInt8_Sub)
+}
+
+func (b *Buffer) PeekInt8_Neg() (int8, error) {
+	return peek(b, (*Buffer).ReadInt8_Neg)
+}
+
+func (b *Buffer) PeekInt8_Mirror() (int8, error) {
+	return peek(b, (*Buffer).ReadInt8_Mirror)
+}
+
+func (b *Buffer) PeekUint16() (uint16, error) {
+	return peek(b, (*Buffer).ReadUint16)
+}
+
+func (b *Buffer) PeekUint16_Sub() (uint16, error) {
+	return peek(b, (*Buffer).ReadUint16_Sub)
+}
+
+func (b *Buffer) PeekUint16LE() (uint16, error) {
+	return peek(b, (*Buffer).ReadUint16LE)
+}
+
+func (b *Buffer) PeekUint16LE_Sub() (uint16, error) {
+	return peek(b, (*Buffer).ReadUint16LE_Sub)
+}
+
+func (b *Buffer) PeekInt16() (int16, error) {
+	return peek(b, (*Buffer).ReadInt16)
+}
+
+func (b *Buffer) PeekInt16LE() (int16, error) {
+	return peek(b, (*Buffer).ReadInt16LE)
+}
+
+func (b *Buffer) PeekUint24() (uint32, error) {
+	return peek(b, (*Buffer).ReadUint24)
+}
+
+func (b *Buffer) PeekUint24LE() (uint32, error) {
+	return peek(b, (*Buffer).ReadUint24LE)
+}
+
+func (b *Buffer) PeekInt24() (int32, error) {
+	return peek(b, (*Buffer).ReadInt24)
+}
+
+func (b *Buffer) PeekInt24LE() (int32, error) {
+	return peek(b, (*Buffer).ReadInt24LE)
+}
+
+func (b *Buffer) PeekUint32() (uint32, error) {
+	return peek(b, (*Buffer).ReadUint32)
+}
+
+func (b *Buffer) PeekUint32LE() (uint32, error) {
+	return peek(b, (*Buffer).ReadUint32LE)
+}
+
+func (b *Buffer) PeekUint32V1() (uint32, error) {
+	return peek(b, (*Buffer).ReadUint32V1)
+}
+
+func (b *Buffer) PeekUint32V2() (uint32, error) {
+	return peek(b, (*Buffer).ReadUint32V2)
+}
+
+func (b *Buffer) PeekInt32() (int32, error) {
+	return peek(b, (*Buffer).ReadInt32)
+}
+
+func (b *Buffer) PeekInt32LE() (int32, error) {
+	return peek(b, (*Buffer).ReadInt32LE)
+}
+
+func (b *Buffer) PeekInt32V1() (int32, error) {
+	return peek(b, (*Buffer).ReadInt32V1)
+}
+
+func (b *Buffer) PeekInt32V2() (int32, error) {
+	return peek(b, (*Buffer).ReadInt32V2)
+}
+
+func (b *Buffer) PeekUint40() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint40)
+}
+
+func (b *Buffer) PeekUint40LE() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint40LE)
+}
+
+func (b *Buffer) PeekInt40() (int64, error) {
+	return peek(b, (*Buffer).ReadInt40)
+}
+
+func (b *Buffer) PeekInt40LE() (int64, error) {
+	return peek(b, (*Buffer).ReadInt40LE)
+}
+
+func (b *Buffer) PeekUint48() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint48)
+}
+
+func (b *Buffer) PeekUint48LE() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint48LE)
+}
+
+func (b *Buffer) PeekInt48() (int64, error) {
+	return peek(b, (*Buffer).ReadInt48)
+}
+
+func (b *Buffer) PeekInt48LE() (int64, error) {
+	return peek(b, (*Buffer).ReadInt48LE)
+}
+
+func (b *Buffer) PeekUint56() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint56)
+}
+
+func (b *Buffer) PeekUint56LE() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint56LE)
+}
+
+func (b *Buffer) PeekInt56() (int64, error) {
+	return peek(b, (*Buffer).ReadInt56)
+}
+
+func (b *Buffer) PeekInt56LE() (int64, error) {
+	return peek(b, (*Buffer).ReadInt56LE)
+}
+
+func (b *Buffer) PeekUint64() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint64)
+}
+
+func (b *Buffer) PeekUint64LE() (uint64, error) {
+	return peek(b, (*Buffer).ReadUint64LE)
+}
+
+func (b *Buffer) PeekInt64() (int64, error) {
+	return peek(b, (*Buffer).ReadInt64)
+}
+
+func (b *Buffer) PeekInt64LE() (int64, error) {
+	return peek(b, (*Buffer).ReadInt64LE)
+}
+
+func (b *Buffer) PeekFloat32() (float32, error) {
+	return peek(b, (*Buffer).ReadFloat32)
+}
+
+func (b *Buffer) PeekFloat32LE() (float32, error) {
+	return peek(b, (*Buffer).ReadFloat32LE)
+}
+
+func (b *Buffer) PeekFloat64() (float64, error) {
+	return peek(b, (*Buffer).ReadFloat64)
+}
+
+func (b *Buffer) PeekFloat64LE() (float64, error) {
+	return peek(b, (*Buffer).ReadFloat64LE)
+}
+
+func (b *Buffer) PeekBool() (bool, error) {
+	return peek(b, (*Buffer).ReadBool)
+}
+
+func (b *Buffer) PeekBoolStrict() (bool, error) {
+	return peek(b, (*Buffer).ReadBoolStrict)
+}
+
+func (b *Buffer) PeekSmart() (uint16, error) {
+	return peek(b, (*Buffer).ReadSmart)
+}
+
+func (b *Buffer) PeekSignedSmart() (int16, error) {
+	return peek(b, (*Buffer).ReadSignedSmart)
+}
+
+func (b *Buffer) PeekVarInt() (uint64, error) {
+	return peek(b, (*Buffer).ReadVarInt)
+}
+
+func (b *Buffer) PeekSignedVarInt() (int64, error) {
+	return peek(b, (*Buffer).ReadSignedVarInt)
+}
+
+func (b *Buffer) PeekString() (string, error) {
+	return peek(b, (*Buffer).ReadString)
+}
+
+func (b *Buffer) PeekJagString() (string, error) {
+	return peek(b, (*Buffer).ReadJagString)
+}
+
+func (b *Buffer) PeekVersionedString(version uint8) (string, error) {
+	return peek(b, func(b *Buffer) (string, error) { return b.ReadVersionedString(version) })
+}
+
+func (b *Buffer) PeekNullableString() (*string, error) {
+	return peek(b, (*Buffer).ReadNullableString)
+}
+
+func (b *Buffer) PeekCESU8String() (string, error) {
+	return peek(b, (*Buffer).ReadCESU8String)
+}
+
+func (b *Buffer) PeekChar() (uint16, error) {
+	return peek(b, (*Buffer).ReadChar)
+}
+
+func (b *Buffer) PeekUTF() (string, error) {
+	return peek(b, (*Buffer).ReadUTF)
+}
+
+func (b *Buffer) PeekUintN(n int, o Order, t Transform) (uint64, error) {
+	return peek(b, func(b *Buffer) (uint64, error) { return b.ReadUintN(n, o, t) })
+}
+
+func (b *Buffer) PeekIntN(n int, o Order, t Transform) (int64, error) {
+	return peek(b, func(b *Buffer) (int64, error) { return b.ReadIntN(n, o, t) })
+}
+
+// PeekBytes fills dst with the next len(dst) bytes, failing with io.EOF if
+// fewer are available.
+func (b *Buffer) PeekBytes(dst []byte) error {
+	_, err := peek(b, func(b *Buffer) (struct{}, error) { return struct{}{}, b.ReadBytes(dst) })
+	return err
+}
+
+func (b *Buffer) PeekBase37() (string, error) {
+	return peek(b, (*Buffer).ReadBase37)
+}
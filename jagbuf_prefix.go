@@ -0,0 +1,257 @@
+package jagbuf
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+)
+
+// Prefix is the wire type of the count in front of a collection.
+type Prefix int
+
+const (
+	PrefixUint8 Prefix = iota
+	PrefixUint16
+	PrefixUint24
+	PrefixUint32
+	PrefixSmart
+	PrefixVarInt
+)
+
+func (p Prefix) String() string {
+	switch p {
+	case PrefixUint8:
+		return "u8"
+	case PrefixUint16:
+		return "u16"
+	case PrefixUint24:
+		return "u24"
+	case PrefixUint32:
+		return "u32"
+	case PrefixSmart:
+		return "smart"
+	case PrefixVarInt:
+		return "varint"
+	}
+	return fmt.Sprintf("Prefix(%d)", int(p))
+}
+
+// max returns the largest count that can be written with the prefix.
+func (p Prefix) max() uint64 {
+	switch p {
+	case PrefixUint8:
+		return math.MaxUint8
+	case PrefixUint16:
+		return math.MaxUint16
+	case PrefixUint24:
+		return 1<<24 - 1
+	case PrefixUint32:
+		return math.MaxUint32
+	case PrefixSmart:
+		return MaxSmart
+	case PrefixVarInt:
+		return math.MaxInt64
+	}
+	panic(fmt.Sprintf("jagbuf: invalid prefix %v", p))
+}
+
+// ErrCountTooLarge is returned when a count prefix is larger than the
+// maximum allowed by the caller.
+var ErrCountTooLarge = errors.New("jagbuf: count too large")
+
+func readCount(r Reader, p Prefix, max int) (int, error) {
+	var n uint64
+	var err error
+
+	switch p {
+	case PrefixUint8:
+		var v uint8
+		v, err = r.ReadUint8()
+		n = uint64(v)
+	case PrefixUint16:
+		var v uint16
+		v, err = r.ReadUint16()
+		n = uint64(v)
+	case PrefixUint24:
+		var v uint32
+		v, err = r.ReadUint24()
+		n = uint64(v)
+	case PrefixUint32:
+		var v uint32
+		v, err = r.ReadUint32()
+		n = uint64(v)
+	case PrefixSmart:
+		var v uint16
+		v, err = r.ReadSmart()
+		n = uint64(v)
+	case PrefixVarInt:
+		n, err = r.ReadVarInt()
+	default:
+		panic(fmt.Sprintf("jagbuf: invalid prefix %v", p))
+	}
+
+	if err != nil {
+		return 0, err
+	}
+	if max < 0 || n > uint64(max) {
+		return 0, fmt.Errorf("%w: %d exceeds %d", ErrCountTooLarge, n, max)
+	}
+
+	return int(n), nil
+}
+
+func writeCount(w Writer, p Prefix, n int) {
+	if n < 0 || uint64(n) > p.max() {
+		panic(fmt.Sprintf("jagbuf: count %d out of range for %v prefix", n, p))
+	}
+
+	switch p {
+	case PrefixUint8:
+		w.WriteUint8(uint8(n))
+	case PrefixUint16:
+		w.WriteUint16(uint16(n))
+	case PrefixUint24:
+		w.WriteUint24(uint32(n))
+	case PrefixUint32:
+		w.WriteUint32(uint32(n))
+	case PrefixSmart:
+		w.WriteSmart(uint16(n))
+	case PrefixVarInt:
+		w.WriteVarInt(uint64(n))
+	}
+}
+
+// ReadCount reads a count encoded as p. A count greater than max fails with
+// an error wrapping ErrCountTooLarge.
+func (b *Buffer) ReadCount(p Prefix, max int) (int, error) {
+	return readCount(b, p, max)
+}
+
+// WriteCount writes the count n encoded as p. It panics if n cannot be
+// encoded as p.
+func (b *Buffer) WriteCount(p Prefix, n int) {
+	writeCount(b, p, n)
+}
+
+// ReadBytesPrefixed reads a count encoded as p, followed by that many bytes.
+// A count greater than max fails with an error wrapping ErrCountTooLarge,
+// and one greater than the remaining data with io.EOF, before anything is
+// allocated.
+func (b *Buffer) ReadBytesPrefixed(p Prefix, max int) ([]byte, error) {
+	start := b.readIndex
+
+	n, err := b.ReadCount(p, max)
+	if err == nil && n > b.ReadableBytes() {
+		err = io.EOF
+	}
+	if err != nil {
+		b.readIndex = start
+		return nil, err
+	}
+
+	data := make([]byte, n)
+	copy(data, b.data[b.readIndex:])
+	b.readIndex += n
+
+	return data, nil
+}
+
+// WriteBytesPrefixed writes the length of data encoded as p, followed by
+// data. It panics if the length cannot be encoded as p.
+func (b *Buffer) WriteBytesPrefixed(p Prefix, data []byte) {
+	b.WriteCount(p, len(data))
+	b.Write(data)
+}
+
+// maxPrealloc bounds the capacity allocated up front for a collection read
+// from a stream, where the remaining length is unknown.
+const maxPrealloc = 1024
+
+// preallocSize returns the capacity to allocate for n elements read from r.
+// Every element takes at least a byte, so a Buffer never needs more than
+// its remaining length.
+func preallocSize(r any, n int) int {
+	if b, ok := r.(*Buffer); ok {
+		return min(n, b.ReadableBytes())
+	}
+	return min(n, maxPrealloc)
+}
+
+// ReadPrefixed reads a count encoded as p, followed by that many elements
+// decoded with read. A count greater than max fails with an error wrapping
+// ErrCountTooLarge. The slice grows as elements are read, so a malicious
+// count cannot cause a large allocation.
+//
+//	ids, err := jagbuf.ReadPrefixed(b, jagbuf.PrefixSmart, 256, (*jagbuf.Buffer).ReadUint16)
+func ReadPrefixed[R Reader, T any](r R, p Prefix, max int, read func(R) (T, error)) ([]T, error) {
+	n, err := r.ReadCount(p, max)
+	if err != nil {
+		return nil, err
+	}
+
+	v := make([]T, 0, preallocSize(r, n))
+	for i := 0; i < n; i++ {
+		elem, err := read(r)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		v = append(v, elem)
+	}
+
+	return v, nil
+}
+
+// WritePrefixed writes the length of src encoded as p, followed by every
+// element encoded with write. It panics if the length cannot be encoded as
+// p.
+func WritePrefixed[W Writer, T any](w W, p Prefix, src []T, write func(W, T)) {
+	w.WriteCount(p, len(src))
+	for _, elem := range src {
+		write(w, elem)
+	}
+}
+
+// ReadPrefixedMap reads a count encoded as p, followed by that many keys
+// and values decoded with readKey and readValue. It is bounded in the same
+// way as ReadPrefixed.
+func ReadPrefixedMap[R Reader, K comparable, V any](r R, p Prefix, max int, readKey func(R) (K, error), readValue func(R) (V, error)) (map[K]V, error) {
+	n, err := r.ReadCount(p, max)
+	if err != nil {
+		return nil, err
+	}
+
+	m := make(map[K]V, preallocSize(r, n))
+	for i := 0; i < n; i++ {
+		key, err := readKey(r)
+		if err != nil {
+			return nil, fmt.Errorf("index %d: %w", i, err)
+		}
+		if m[key], err = readValue(r); err != nil {
+			return nil, fmt.Errorf("key %v: %w", key, err)
+		}
+	}
+
+	return m, nil
+}
+
+// WritePrefixedMap writes the length of m encoded as p, followed by every
+// key and value encoded with writeKey and writeValue. Entries are written in
+// key order so the output is deterministic. It panics if the length cannot
+// be encoded as p.
+func WritePrefixedMap[W Writer, K cmp.Ordered, V any](w W, p Prefix, m map[K]V, writeKey func(W, K), writeValue func(W, V)) {
+	w.WriteCount(p, len(m))
+
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	for _, k := range keys {
+		writeKey(w, k)
+		writeValue(w, m[k])
+	}
+}
@@ -0,0 +1,138 @@
+package jagbuf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"testing/iotest"
+)
+
+var testPrefixes = []Prefix{PrefixUint8, PrefixUint16, PrefixUint24, PrefixUint32, PrefixSmart, PrefixVarInt}
+
+func TestBuffer_ReadWriteCount(t *testing.T) {
+	for _, p := range testPrefixes {
+		for _, n := range []int{0, 1, 127, 128, 255} {
+			buffer := NewBuffer()
+			buffer.WriteCount(p, n)
+
+			count, err := buffer.ReadCount(p, 255)
+			if err != nil || count != n || buffer.ReadableBytes() != 0 {
+				t.Errorf("ReadCount(%v) fail: expected %d, got %d (%v)", p, n, count, err)
+			}
+		}
+	}
+}
+
+func TestBuffer_ReadCount_TooLarge(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteCount(PrefixUint32, 1<<20)
+
+	if _, err := buffer.ReadCount(PrefixUint32, 1000); !errors.Is(err, ErrCountTooLarge) {
+		t.Errorf("ReadCount fail: expected ErrCountTooLarge, got %v", err)
+	}
+}
+
+func TestBuffer_WriteCount_OutOfRange(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("WriteCount fail: expected a panic for a count of 256 with a u8 prefix")
+		}
+	}()
+
+	NewBuffer().WriteCount(PrefixUint8, 256)
+}
+
+func TestBuffer_ReadWriteBytesPrefixed(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteBytesPrefixed(PrefixSmart, []byte("hello"))
+	buffer.WriteBytesPrefixed(PrefixVarInt, bytes.Repeat([]byte{1}, 300))
+
+	hello, err := buffer.ReadBytesPrefixed(PrefixSmart, 5)
+	if err != nil || string(hello) != "hello" {
+		t.Errorf("ReadBytesPrefixed fail: expected \"hello\", got %q (%v)", hello, err)
+	}
+
+	ones, err := buffer.ReadBytesPrefixed(PrefixVarInt, 300)
+	if err != nil || !bytes.Equal(ones, bytes.Repeat([]byte{1}, 300)) {
+		t.Errorf("ReadBytesPrefixed fail: expected 300 bytes, got %d (%v)", len(ones), err)
+	}
+}
+
+func TestBuffer_ReadBytesPrefixed_Truncated(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteUint32(1 << 30)
+	buffer.WriteUint8(0)
+
+	if _, err := buffer.ReadBytesPrefixed(PrefixUint32, 1<<30); err != io.EOF {
+		t.Errorf("ReadBytesPrefixed fail: expected io.EOF, got %v", err)
+	}
+	if buffer.ReadableBytes() != 5 {
+		t.Errorf("ReadBytesPrefixed fail: expected nothing consumed, %d bytes left", buffer.ReadableBytes())
+	}
+}
+
+func TestReadWritePrefixed(t *testing.T) {
+	ids := []uint16{1, 2, 0xFFFF}
+	params := map[uint32]string{3: "c", 1: "a", 2: "b"}
+
+	encode := func(w Writer) {
+		WritePrefixed(w, PrefixUint8, ids, Writer.WriteUint16)
+		WritePrefixedMap(w, PrefixVarInt, params, Writer.WriteUint24, Writer.WriteString)
+	}
+
+	expected := NewBuffer()
+	encode(expected)
+
+	out := &bytes.Buffer{}
+	writer := NewStreamWriterSize(out, 16)
+	encode(writer)
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), expected.Bytes()) {
+		t.Fatalf("WritePrefixed fail: expected %v, got %v", expected.Bytes(), out.Bytes())
+	}
+
+	decode := func(r Reader) []any {
+		readIDs, err := ReadPrefixed(r, PrefixUint8, 3, Reader.ReadUint16)
+		if err != nil {
+			t.Fatal(err)
+		}
+		readParams, err := ReadPrefixedMap(r, PrefixVarInt, 3, Reader.ReadUint24, Reader.ReadString)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []any{readIDs, readParams}
+	}
+
+	for _, r := range []Reader{
+		Wrap(expected.Bytes()),
+		NewStreamReaderSize(iotest.OneByteReader(bytes.NewReader(expected.Bytes())), 16),
+	} {
+		actual := decode(r)
+		if fmt.Sprint(actual) != fmt.Sprint([]any{ids, params}) {
+			t.Errorf("ReadPrefixed fail: expected %v, got %v", []any{ids, params}, actual)
+		}
+	}
+}
+
+func TestReadPrefixed_LargeCount(t *testing.T) {
+	data := []byte{0x7F, 0xFF, 0xFF, 0xFF, 0, 1}
+
+	if _, err := ReadPrefixed(Wrap(data), PrefixUint32, 10, (*Buffer).ReadUint16); !errors.Is(err, ErrCountTooLarge) {
+		t.Errorf("ReadPrefixed fail: expected ErrCountTooLarge, got %v", err)
+	}
+
+	reader := NewStreamReader(bytes.NewReader(data))
+	if _, err := ReadPrefixed(reader, PrefixUint32, math.MaxInt32, (*StreamReader).ReadUint16); !errors.Is(err, io.EOF) {
+		t.Errorf("ReadPrefixed fail: expected io.EOF, got %v", err)
+	}
+
+	reader = NewStreamReader(bytes.NewReader(data))
+	if _, err := reader.ReadBytesPrefixed(PrefixUint32, math.MaxInt32); err != io.ErrUnexpectedEOF {
+		t.Errorf("ReadBytesPrefixed fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+}
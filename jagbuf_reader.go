@@ -0,0 +1,104 @@
+package jagbuf
+
+import "io"
+
+// Reader is the read side of the Buffer method set. It is implemented by
+// Buffer for in-memory data and by StreamReader for data that is decoded as
+// it arrives from an io.Reader.
+type Reader interface {
+	io.Reader
+
+	ReadUint8() (uint8, error)
+	ReadInt8() (int8, error)
+	ReadUint8_Sub() (uint8, error)
+	ReadUint8_Neg() (uint8, error)
+	ReadUint8_Mirror() (uint8, error)
+	ReadInt8_Sub() (int8, error)
+	ReadInt8_Neg() (int8, error)
+	ReadInt8_Mirror() (int8, error)
+	ReadUint16() (uint16, error)
+	ReadUint16_Sub() (uint16, error)
+	ReadUint16LE() (uint16, error)
+	ReadUint16LE_Sub() (uint16, error)
+	ReadInt16() (int16, error)
+	ReadInt16LE() (int16, error)
+	ReadUint24() (uint32, error)
+	ReadUint24LE() (uint32, error)
+	ReadInt24() (int32, error)
+	ReadInt24LE() (int32, error)
+	ReadUint32() (uint32, error)
+	ReadUint32LE() (uint32, error)
+	ReadUint32V1() (uint32, error)
+	ReadUint32V2() (uint32, error)
+	ReadInt32() (int32, error)
+	ReadInt32LE() (int32, error)
+	ReadInt32V1() (int32, error)
+	ReadInt32V2() (int32, error)
+	ReadUint40() (uint64, error)
+	ReadUint40LE() (uint64, error)
+	ReadInt40() (int64, error)
+	ReadInt40LE() (int64, error)
+	ReadUint48() (uint64, error)
+	ReadUint48LE() (uint64, error)
+	ReadInt48() (int64, error)
+	ReadInt48LE() (int64, error)
+	ReadUint56() (uint64, error)
+	ReadUint56LE() (uint64, error)
+	ReadInt56() (int64, error)
+	ReadInt56LE() (int64, error)
+	ReadUint64() (uint64, error)
+	ReadUint64LE() (uint64, error)
+	ReadInt64() (int64, error)
+	ReadInt64LE() (int64, error)
+
+	ReadFloat32() (float32, error)
+	ReadFloat32LE() (float32, error)
+	ReadFloat64() (float64, error)
+	ReadFloat64LE() (float64, error)
+
+	ReadBool() (bool, error)
+	ReadBoolStrict() (bool, error)
+
+	ReadSmart() (uint16, error)
+	ReadSignedSmart() (int16, error)
+	ReadVarInt() (uint64, error)
+	ReadSignedVarInt() (int64, error)
+
+	ReadUintN(n int, o Order, t Transform) (uint64, error)
+	ReadIntN(n int, o Order, t Transform) (int64, error)
+
+	ReadUint8s(dst []uint8, t Transform) error
+	ReadInt8s(dst []int8, t Transform) error
+	ReadUint16s(dst []uint16, o Order, t Transform) error
+	ReadInt16s(dst []int16, o Order, t Transform) error
+	ReadUint24s(dst []uint32, o Order, t Transform) error
+	ReadInt24s(dst []int32, o Order, t Transform) error
+	ReadUint32s(dst []uint32, o Order, t Transform) error
+	ReadInt32s(dst []int32, o Order, t Transform) error
+	ReadUint64s(dst []uint64, o Order, t Transform) error
+	ReadInt64s(dst []int64, o Order, t Transform) error
+
+	ReadBytes(dst []byte) error
+	ReadBytesAdd(dst []byte) error
+	ReadBytesReversed(dst []byte) error
+	ReadBytesReversedAdd(dst []byte) error
+
+	ReadCount(p Prefix, max int) (int, error)
+	ReadBytesPrefixed(p Prefix, max int) ([]byte, error)
+
+	ReadString() (string, error)
+	ReadJagString() (string, error)
+	ReadVersionedString(version uint8) (string, error)
+	ReadNullableString() (*string, error)
+	ReadCESU8String() (string, error)
+
+	ReadChar() (uint16, error)
+	ReadUTF() (string, error)
+
+	ReadBase37() (string, error)
+}
+
+var (
+	_ Reader = (*Buffer)(nil)
+	_ Reader = (*StreamReader)(nil)
+)
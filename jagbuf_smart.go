@@ -0,0 +1,80 @@
+package jagbuf
+
+import (
+	"fmt"
+	"io"
+)
+
+const (
+	// MaxSmart is the largest value that can be written with WriteSmart.
+	MaxSmart = 0x7FFF
+	// MinSignedSmart and MaxSignedSmart bound the values that can be
+	// written with WriteSignedSmart.
+	MinSignedSmart = -0x4000
+	MaxSignedSmart = 0x3FFF
+)
+
+// ReadSmart reads an unsigned "smart", a value stored in a single byte when
+// it is below 128, or otherwise in 2 bytes with the high bit set.
+func (b *Buffer) ReadSmart() (uint16, error) {
+	if b.ReadableBytes() < 1 {
+		return 0, io.EOF
+	}
+
+	if b.data[b.readIndex] < 0x80 {
+		val, err := b.ReadUint8()
+		return uint16(val), err
+	}
+
+	val, err := b.ReadUint16()
+	if err != nil {
+		return 0, err
+	}
+	return val - 0x8000, nil
+}
+
+// ReadSignedSmart reads a signed "smart", a value stored in a single byte
+// when it is between -64 and 63, or otherwise in 2 bytes with the high bit
+// set.
+func (b *Buffer) ReadSignedSmart() (int16, error) {
+	if b.ReadableBytes() < 1 {
+		return 0, io.EOF
+	}
+
+	if b.data[b.readIndex] < 0x80 {
+		val, err := b.ReadUint8()
+		return int16(val) - 0x40, err
+	}
+
+	val, err := b.ReadUint16()
+	if err != nil {
+		return 0, err
+	}
+	return int16(val - 0xC000), nil
+}
+
+// WriteSmart writes v as an unsigned "smart". It panics if v is greater than
+// MaxSmart.
+func (b *Buffer) WriteSmart(v uint16) {
+	switch {
+	case v < 0x80:
+		b.WriteUint8(uint8(v))
+	case v <= MaxSmart:
+		b.WriteUint16(v + 0x8000)
+	default:
+		panic(fmt.Sprintf("jagbuf: smart %d out of range", v))
+	}
+}
+
+// WriteSignedSmart writes v as a signed "smart". It panics if v is not
+// between MinSignedSmart and MaxSignedSmart.
+func (b *Buffer) WriteSignedSmart(v int16) {
+	switch {
+	case v >= -0x40 && v < 0x40:
+		b.WriteUint8(uint8(v + 0x40))
+	case v >= MinSignedSmart && v <= MaxSignedSmart:
+		b.WriteUint16(uint16(v) + 0xC000)
+	default:
+		panic(fmt.Sprintf("jagbuf: signed smart %d out of range", v))
+	}
+}
@@ -0,0 +1,811 @@
+package jagbuf
+
+import (
+	"bufio"
+	"io"
+	"math"
+)
+
+const defaultStreamSize = 4096
+
+// StreamReader decodes the same formats as Buffer directly from an
+// io.Reader, so large inputs do not need to be loaded into memory first.
+// Each Read* method behaves like the Buffer method of the same name, except
+// that a value cut short by the end of the stream fails with
+// io.ErrUnexpectedEOF and the partial bytes are consumed.
+type StreamReader struct {
+	r *bufio.Reader
+
+	// scratch holds the bytes of the value currently being decoded, so
+	// decoding is shared with Buffer.
+	scratch Buffer
+}
+
+// NewStreamReader creates a StreamReader reading from r with a default
+// internal buffer size.
+func NewStreamReader(r io.Reader) *StreamReader {
+	return NewStreamReaderSize(r, defaultStreamSize)
+}
+
+// NewStreamReaderSize creates a StreamReader reading from r with an internal
+// buffer of at least size bytes.
+func NewStreamReaderSize(r io.Reader, size int) *StreamReader {
+	return &StreamReader{
+		r:       bufio.NewReaderSize(r, size),
+		scratch: Buffer{data: make([]byte, 8)},
+	}
+}
+
+// next reads exactly n bytes from the stream into the scratch buffer.
+func (r *StreamReader) next(n int) (*Buffer, error) {
+	r.scratch.Reset()
+	r.scratch.ensureWritable(n)
+
+	if _, err := io.ReadFull(r.r, r.scratch.data[:n]); err != nil {
+		return nil, err
+	}
+
+	r.scratch.writeIndex = n
+	return &r.scratch, nil
+}
+
+// Read reads up to len(dst) bytes from the stream, implementing io.Reader.
+func (r *StreamReader) Read(dst []byte) (int, error) {
+	return r.r.Read(dst)
+}
+
+// Skip discards the next n bytes of the stream.
+func (r *StreamReader) Skip(n int) error {
+	discarded, err := r.r.Discard(n)
+	if err == io.EOF && discarded > 0 {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+func (r *StreamReader) ReadUint8() (uint8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint8()
+}
+
+func (r *StreamReader) ReadInt8() (int8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt8()
+}
+
+func (r *StreamReader) ReadUint8_Sub() (uint8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint8_Sub()
+}
+
+func (r *StreamReader) ReadUint8_Neg() (uint8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint8_Neg()
+}
+
+func (r *StreamReader) ReadUint8_Mirror() (uint8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint8_Mirror()
+}
+
+func (r *StreamReader) ReadInt8_Sub() (int8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt8_Sub()
+}
+
+func (r *StreamReader) ReadInt8_Neg() (int8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt8_Neg()
+}
+
+func (r *StreamReader) ReadInt8_Mirror() (int8, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt8_Mirror()
+}
+
+func (r *StreamReader) ReadUint16() (uint16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint16()
+}
+
+func (r *StreamReader) ReadUint16_Sub() (uint16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint16_Sub()
+}
+
+func (r *StreamReader) ReadUint16LE() (uint16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint16LE()
+}
+
+func (r *StreamReader) ReadUint16LE_Sub() (uint16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint16LE_Sub()
+}
+
+func (r *StreamReader) ReadInt16() (int16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt16()
+}
+
+func (r *StreamReader) ReadInt16LE() (int16, error) {
+	b, err := r.next(2)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt16LE()
+}
+
+func (r *StreamReader) ReadUint24() (uint32, error) {
+	b, err := r.next(3)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint24()
+}
+
+func (r *StreamReader) ReadUint24LE() (uint32, error) {
+	b, err := r.next(3)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint24LE()
+}
+
+func (r *StreamReader) ReadInt24() (int32, error) {
+	b, err := r.next(3)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt24()
+}
+
+func (r *StreamReader) ReadInt24LE() (int32, error) {
+	b, err := r.next(3)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt24LE()
+}
+
+func (r *StreamReader) ReadUint32() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint32()
+}
+
+func (r *StreamReader) ReadUint32LE() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint32LE()
+}
+
+func (r *StreamReader) ReadUint32V1() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint32V1()
+}
+
+func (r *StreamReader) ReadUint32V2() (uint32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint32V2()
+}
+
+func (r *StreamReader) ReadInt32() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt32()
+}
+
+func (r *StreamReader) ReadInt32LE() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt32LE()
+}
+
+func (r *StreamReader) ReadInt32V1() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt32V1()
+}
+
+func (r *StreamReader) ReadInt32V2() (int32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt32V2()
+}
+
+func (r *StreamReader) ReadUint40() (uint64, error) {
+	b, err := r.next(5)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint40()
+}
+
+func (r *StreamReader) ReadUint40LE() (uint64, error) {
+	b, err := r.next(5)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint40LE()
+}
+
+func (r *StreamReader) ReadInt40() (int64, error) {
+	b, err := r.next(5)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt40()
+}
+
+func (r *StreamReader) ReadInt40LE() (int64, error) {
+	b, err := r.next(5)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt40LE()
+}
+
+func (r *StreamReader) ReadUint48() (uint64, error) {
+	b, err := r.next(6)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint48()
+}
+
+func (r *StreamReader) ReadUint48LE() (uint64, error) {
+	b, err := r.next(6)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint48LE()
+}
+
+func (r *StreamReader) ReadInt48() (int64, error) {
+	b, err := r.next(6)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt48()
+}
+
+func (r *StreamReader) ReadInt48LE() (int64, error) {
+	b, err := r.next(6)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt48LE()
+}
+
+func (r *StreamReader) ReadUint56() (uint64, error) {
+	b, err := r.next(7)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint56()
+}
+
+func (r *StreamReader) ReadUint56LE() (uint64, error) {
+	b, err := r.next(7)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint56LE()
+}
+
+func (r *StreamReader) ReadInt56() (int64, error) {
+	b, err := r.next(7)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt56()
+}
+
+func (r *StreamReader) ReadInt56LE() (int64, error) {
+	b, err := r.next(7)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt56LE()
+}
+
+func (r *StreamReader) ReadUint64() (uint64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint64()
+}
+
+func (r *StreamReader) ReadUint64LE() (uint64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUint64LE()
+}
+
+func (r *StreamReader) ReadInt64() (int64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt64()
+}
+
+func (r *StreamReader) ReadInt64LE() (int64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadInt64LE()
+}
+
+func (r *StreamReader) ReadFloat32() (float32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadFloat32()
+}
+
+func (r *StreamReader) ReadFloat32LE() (float32, error) {
+	b, err := r.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadFloat32LE()
+}
+
+func (r *StreamReader) ReadFloat64() (float64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadFloat64()
+}
+
+func (r *StreamReader) ReadFloat64LE() (float64, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadFloat64LE()
+}
+
+func (r *StreamReader) ReadBool() (bool, error) {
+	b, err := r.next(1)
+	if err != nil {
+		return false, err
+	}
+	return b.ReadBool()
+}
+
+func (r *StreamReader) ReadBoolStrict() (bool, error) {
+	peek, err := r.r.Peek(1)
+	if err != nil {
+		return false, err
+	}
+
+	if err := checkBool(peek[0]); err != nil {
+		return false, err
+	}
+
+	return r.ReadBool()
+}
+
+func (r *StreamReader) ReadSmart() (uint16, error) {
+	b, err := r.nextSmart()
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadSmart()
+}
+
+func (r *StreamReader) ReadSignedSmart() (int16, error) {
+	b, err := r.nextSmart()
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadSignedSmart()
+}
+
+// ReadVarInt reads a varint a byte at a time, as its length is not known
+// up front.
+func (r *StreamReader) ReadVarInt() (uint64, error) {
+	var v uint64
+	for i := 0; ; i++ {
+		if i == MaxVarIntLen {
+			return 0, errVarIntOverflow
+		}
+
+		c, err := r.r.ReadByte()
+		if err != nil {
+			if err == io.EOF && i > 0 {
+				return 0, io.ErrUnexpectedEOF
+			}
+			return 0, err
+		}
+
+		if v>>57 != 0 {
+			return 0, errVarIntOverflow
+		}
+
+		v = v<<7 | uint64(c&0x7F)
+		if c < 0x80 {
+			return v, nil
+		}
+	}
+}
+
+func (r *StreamReader) ReadSignedVarInt() (int64, error) {
+	v, err := r.ReadVarInt()
+	return unzigzag(v), err
+}
+
+// nextSmart reads a 1 or 2 byte smart into the scratch buffer, depending on
+// the high bit of its first byte.
+func (r *StreamReader) nextSmart() (*Buffer, error) {
+	peek, err := r.r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+
+	if peek[0] < 0x80 {
+		return r.next(1)
+	}
+	return r.next(2)
+}
+
+func (r *StreamReader) ReadUintN(n int, o Order, t Transform) (uint64, error) {
+	checkLayout(n, o, t)
+
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadUintN(n, o, t)
+}
+
+func (r *StreamReader) ReadIntN(n int, o Order, t Transform) (int64, error) {
+	checkLayout(n, o, t)
+
+	b, err := r.next(n)
+	if err != nil {
+		return 0, err
+	}
+	return b.ReadIntN(n, o, t)
+}
+
+func (r *StreamReader) ReadUint8s(dst []uint8, t Transform) error {
+	checkLayout(1, BigEndian, t)
+
+	b, err := r.next(len(dst))
+	if err != nil {
+		return err
+	}
+	return b.ReadUint8s(dst, t)
+}
+
+func (r *StreamReader) ReadInt8s(dst []int8, t Transform) error {
+	checkLayout(1, BigEndian, t)
+
+	b, err := r.next(len(dst))
+	if err != nil {
+		return err
+	}
+	return b.ReadInt8s(dst, t)
+}
+
+func (r *StreamReader) ReadUint16s(dst []uint16, o Order, t Transform) error {
+	checkLayout(2, o, t)
+
+	b, err := r.next(len(dst) * 2)
+	if err != nil {
+		return err
+	}
+	return b.ReadUint16s(dst, o, t)
+}
+
+func (r *StreamReader) ReadInt16s(dst []int16, o Order, t Transform) error {
+	checkLayout(2, o, t)
+
+	b, err := r.next(len(dst) * 2)
+	if err != nil {
+		return err
+	}
+	return b.ReadInt16s(dst, o, t)
+}
+
+func (r *StreamReader) ReadUint24s(dst []uint32, o Order, t Transform) error {
+	checkLayout(3, o, t)
+
+	b, err := r.next(len(dst) * 3)
+	if err != nil {
+		return err
+	}
+	return b.ReadUint24s(dst, o, t)
+}
+
+func (r *StreamReader) ReadInt24s(dst []int32, o Order, t Transform) error {
+	checkLayout(3, o, t)
+
+	b, err := r.next(len(dst) * 3)
+	if err != nil {
+		return err
+	}
+	return b.ReadInt24s(dst, o, t)
+}
+
+func (r *StreamReader) ReadUint32s(dst []uint32, o Order, t Transform) error {
+	checkLayout(4, o, t)
+
+	b, err := r.next(len(dst) * 4)
+	if err != nil {
+		return err
+	}
+	return b.ReadUint32s(dst, o, t)
+}
+
+func (r *StreamReader) ReadInt32s(dst []int32, o Order, t Transform) error {
+	checkLayout(4, o, t)
+
+	b, err := r.next(len(dst) * 4)
+	if err != nil {
+		return err
+	}
+	return b.ReadInt32s(dst, o, t)
+}
+
+func (r *StreamReader) ReadUint64s(dst []uint64, o Order, t Transform) error {
+	checkLayout(8, o, t)
+
+	b, err := r.next(len(dst) * 8)
+	if err != nil {
+		return err
+	}
+	return b.ReadUint64s(dst, o, t)
+}
+
+func (r *StreamReader) ReadInt64s(dst []int64, o Order, t Transform) error {
+	checkLayout(8, o, t)
+
+	b, err := r.next(len(dst) * 8)
+	if err != nil {
+		return err
+	}
+	return b.ReadInt64s(dst, o, t)
+}
+
+func (r *StreamReader) ReadBytesAdd(dst []byte) error {
+	b, err := r.next(len(dst))
+	if err != nil {
+		return err
+	}
+	return b.ReadBytesAdd(dst)
+}
+
+func (r *StreamReader) ReadBytesReversed(dst []byte) error {
+	b, err := r.next(len(dst))
+	if err != nil {
+		return err
+	}
+	return b.ReadBytesReversed(dst)
+}
+
+func (r *StreamReader) ReadBytesReversedAdd(dst []byte) error {
+	b, err := r.next(len(dst))
+	if err != nil {
+		return err
+	}
+	return b.ReadBytesReversedAdd(dst)
+}
+
+// ReadBytes fills dst with bytes from the stream.
+func (r *StreamReader) ReadBytes(dst []byte) error {
+	_, err := io.ReadFull(r.r, dst)
+	return err
+}
+
+func (r *StreamReader) ReadCount(p Prefix, max int) (int, error) {
+	return readCount(r, p, max)
+}
+
+// ReadBytesPrefixed grows the returned slice as data arrives rather than
+// trusting the count, so a count near max on a short stream does not
+// allocate max bytes.
+func (r *StreamReader) ReadBytesPrefixed(p Prefix, max int) ([]byte, error) {
+	n, err := r.ReadCount(p, max)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.r, int64(n)))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < n {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return data, nil
+}
+
+func (r *StreamReader) ReadString() (string, error) {
+	line, err := r.r.ReadSlice(0)
+	if err == nil {
+		return string(line[:len(line)-1]), nil
+	}
+	if err != bufio.ErrBufferFull {
+		if err == io.EOF && len(line) > 0 {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	// Longer than the internal buffer, fall back to an allocating read.
+	// line is only valid until the next read, so copy it first.
+	head := string(line)
+
+	rest, err := r.r.ReadBytes(0)
+	if err != nil {
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	return head + string(rest[:len(rest)-1]), nil
+}
+
+func (r *StreamReader) ReadJagString() (string, error) {
+	return r.ReadVersionedString(0)
+}
+
+// readVersion consumes the version byte in front of a string if it is the
+// expected one.
+func (r *StreamReader) readVersion(expected uint8) error {
+	peek, err := r.r.Peek(1)
+	if err != nil {
+		return err
+	}
+
+	if err := checkVersion(expected, peek[0]); err != nil {
+		return err
+	}
+
+	_, err = r.r.Discard(1)
+	return err
+}
+
+func (r *StreamReader) ReadVersionedString(version uint8) (string, error) {
+	if err := r.readVersion(version); err != nil {
+		return "", err
+	}
+
+	str, err := r.ReadString()
+	if err == io.EOF {
+		return "", io.ErrUnexpectedEOF
+	}
+	return str, err
+}
+
+func (r *StreamReader) ReadNullableString() (*string, error) {
+	str, err := r.ReadString()
+	if err != nil || str == "" {
+		return nil, err
+	}
+	return &str, nil
+}
+
+// ReadCESU8String grows the string as data arrives rather than trusting the
+// length, like ReadBytesPrefixed.
+func (r *StreamReader) ReadCESU8String() (string, error) {
+	if err := r.readVersion(0); err != nil {
+		return "", err
+	}
+
+	n, err := r.ReadVarInt()
+	if err != nil {
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r.r, int64(min(n, math.MaxInt64))))
+	if err != nil {
+		return "", err
+	}
+	if uint64(len(data)) < n {
+		return "", io.ErrUnexpectedEOF
+	}
+
+	return decodeModifiedUTF8(data)
+}
+
+func (r *StreamReader) ReadChar() (uint16, error) {
+	return r.ReadUint16()
+}
+
+func (r *StreamReader) ReadUTF() (string, error) {
+	n, err := r.ReadUint16()
+	if err != nil {
+		return "", err
+	}
+
+	b, err := r.next(int(n))
+	if err != nil {
+		if err == io.EOF {
+			return "", io.ErrUnexpectedEOF
+		}
+		return "", err
+	}
+	return decodeModifiedUTF8(b.data[:n])
+}
+
+func (r *StreamReader) ReadBase37() (string, error) {
+	b, err := r.next(8)
+	if err != nil {
+		return "", err
+	}
+	return b.ReadBase37()
+}
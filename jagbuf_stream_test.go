@@ -0,0 +1,138 @@
+package jagbuf
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestStreamReader_MatchesBuffer(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteUint8(0x90)
+	buffer.WriteUint16LE(0x1020)
+	buffer.WriteInt24(-2)
+	buffer.WriteUint32V1(0x10203040)
+	buffer.WriteUint64(0x1020304050607080)
+	buffer.WriteUint16s([]uint16{1, 2, 3}, BigEndian, TransformAdd)
+	buffer.WriteSignedVarInt(-300)
+	buffer.WriteFloat64LE(-1.5)
+	buffer.WriteBool(true)
+	buffer.WriteInt48(-1)
+	buffer.WriteUTF("utf €")
+	buffer.WriteCESU8String("cesu 😀")
+	buffer.WriteNullableString(nil)
+	buffer.Write(append([]byte(strings.Repeat("long string ", 8)), 0))
+
+	decode := func(r Reader) []any {
+		u8, _ := r.ReadUint8_Sub()
+		u16, _ := r.ReadUint16LE()
+		i24, _ := r.ReadInt24()
+		u32, _ := r.ReadUint32V1()
+		u64, _ := r.ReadUint64()
+		bulk := make([]uint16, 3)
+		_ = r.ReadUint16s(bulk, BigEndian, TransformAdd)
+		varInt, _ := r.ReadSignedVarInt()
+		f64, _ := r.ReadFloat64LE()
+		boolean, _ := r.ReadBoolStrict()
+		i48, _ := r.ReadInt48()
+		utf, _ := r.ReadUTF()
+		cesu, _ := r.ReadCESU8String()
+		null, _ := r.ReadNullableString()
+		str, err := r.ReadString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return []any{u8, u16, i24, u32, u64, bulk, varInt, f64, boolean, i48, utf, cesu, null == nil, str}
+	}
+
+	expected := decode(Wrap(buffer.Bytes()))
+	actual := decode(NewStreamReaderSize(iotest.OneByteReader(bytes.NewReader(buffer.Bytes())), 16))
+
+	if fmt.Sprint(expected) != fmt.Sprint(actual) {
+		t.Errorf("StreamReader fail: expected %v, got %v", expected, actual)
+	}
+}
+
+func TestStreamReader_UnexpectedEOF(t *testing.T) {
+	reader := NewStreamReader(bytes.NewReader([]byte{0x1, 0x2, 0x3}))
+
+	if _, err := reader.ReadUint16(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := reader.ReadUint16(); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("StreamReader fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	if _, err := reader.ReadUint8(); err != io.EOF {
+		t.Errorf("StreamReader fail: expected io.EOF, got %v", err)
+	}
+}
+
+func TestStreamWriter_MatchesBuffer(t *testing.T) {
+	encode := func(w Writer) {
+		w.WriteUint8(0x90)
+		w.WriteUint16LE(0x1020)
+		w.WriteInt24(-2)
+		w.WriteUint32V2(0x10203040)
+		w.WriteUint64(0x1020304050607080)
+		w.WriteVarInt(1 << 40)
+		w.WriteFloat32(0.5)
+		w.WriteBool(false)
+		w.WriteUint56LE(1 << 50)
+		w.WriteUTF("utf €")
+		w.WriteChars("chars")
+		w.WriteCESU8String("cesu 😀")
+		w.WriteVersionedString(3, "versioned")
+		w.WriteUint16s([]uint16{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, LittleEndian, TransformNeg)
+		w.WriteBytesReversedAdd([]byte(strings.Repeat("reversed ", 4)))
+		w.WriteJagString(strings.Repeat("long string ", 8))
+	}
+
+	expected := NewBuffer()
+	encode(expected)
+
+	out := &bytes.Buffer{}
+	writer := NewStreamWriterSize(out, 16)
+	encode(writer)
+
+	if writer.Buffered() > 16 {
+		t.Errorf("StreamWriter fail: buffered %d bytes with a 16 byte buffer", writer.Buffered())
+	}
+
+	if err := writer.Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(expected.Bytes(), out.Bytes()) {
+		t.Errorf("StreamWriter fail: expected %v, got %v", expected.Bytes(), out.Bytes())
+	}
+}
+
+type shortWriter struct{}
+
+func (shortWriter) Write(p []byte) (int, error) {
+	return len(p) / 2, nil
+}
+
+func TestStreamWriter_StickyError(t *testing.T) {
+	writer := NewStreamWriterSize(shortWriter{}, 16)
+
+	writer.WriteUint64(1)
+	if err := writer.Flush(); !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("StreamWriter fail: expected io.ErrShortWrite, got %v", err)
+	}
+
+	writer.WriteUint64(2)
+	if writer.Buffered() != 8 {
+		t.Errorf("StreamWriter fail: accepted data after an error")
+	}
+
+	if err := writer.Flush(); !errors.Is(err, io.ErrShortWrite) {
+		t.Errorf("StreamWriter fail: expected the error to stick, got %v", err)
+	}
+}
@@ -0,0 +1,561 @@
+package jagbuf
+
+import (
+	"fmt"
+	"io"
+	"unicode/utf16"
+)
+
+// minStreamSize is large enough to hold any single fixed width value.
+const minStreamSize = 16
+
+// StreamWriter encodes into a fixed size Buffer and flushes it to an
+// io.Writer whenever it fills up, so arbitrarily large outputs can be
+// written with bounded memory. Each Write* method behaves like the Buffer
+// method of the same name.
+//
+// If an error occurs writing to the io.Writer, no more data is accepted and
+// every later call to Flush returns the error.
+type StreamWriter struct {
+	w   io.Writer
+	buf Buffer
+	err error
+}
+
+// NewStreamWriter creates a StreamWriter writing to w with a default
+// internal buffer size.
+func NewStreamWriter(w io.Writer) *StreamWriter {
+	return NewStreamWriterSize(w, defaultStreamSize)
+}
+
+// NewStreamWriterSize creates a StreamWriter writing to w with an internal
+// buffer of size bytes.
+func NewStreamWriterSize(w io.Writer, size int) *StreamWriter {
+	return &StreamWriter{
+		w:   w,
+		buf: Buffer{data: make([]byte, max(size, minStreamSize))},
+	}
+}
+
+// Flush writes any buffered data to the underlying io.Writer.
+func (w *StreamWriter) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	if w.buf.writeIndex == 0 {
+		return nil
+	}
+
+	n, err := w.w.Write(w.buf.data[:w.buf.writeIndex])
+	if n < w.buf.writeIndex && err == nil {
+		err = io.ErrShortWrite
+	}
+
+	if err != nil {
+		w.err = err
+		return err
+	}
+
+	w.buf.ResetWriteIndex()
+	return nil
+}
+
+// Buffered returns the number of bytes waiting to be flushed.
+func (w *StreamWriter) Buffered() int {
+	return w.buf.writeIndex
+}
+
+// reserve makes room for n bytes, flushing if needed, and returns the
+// buffer to encode into. It returns nil once the writer has failed.
+func (w *StreamWriter) reserve(n int) *Buffer {
+	if w.buf.WritableBytes() < n {
+		_ = w.Flush()
+	}
+
+	if w.err != nil {
+		return nil
+	}
+
+	return &w.buf
+}
+
+// writeChunks passes src to write in chunks that fit the free space of the
+// internal buffer, flushing between them.
+func writeChunks[T any](w *StreamWriter, src []T, width int, write func(*Buffer, []T)) {
+	for len(src) > 0 {
+		b := w.reserve(width)
+		if b == nil {
+			return
+		}
+
+		n := min(len(src), b.WritableBytes()/width)
+		write(b, src[:n])
+		src = src[n:]
+	}
+}
+
+// writeChunksReversed is writeChunks for writers that reverse their input,
+// taking the chunks from the end of src so the output is reversed as a
+// whole.
+func writeChunksReversed(w *StreamWriter, src []byte, write func(*Buffer, []byte)) {
+	for len(src) > 0 {
+		b := w.reserve(1)
+		if b == nil {
+			return
+		}
+
+		n := min(len(src), b.WritableBytes())
+		write(b, src[len(src)-n:])
+		src = src[:len(src)-n]
+	}
+}
+
+// Write buffers data, returning the number of bytes accepted.
+func (w *StreamWriter) Write(data []byte) int {
+	written := 0
+	writeChunks(w, data, 1, func(b *Buffer, chunk []byte) {
+		written += b.Write(chunk)
+	})
+	return written
+}
+
+func (w *StreamWriter) WriteUint8(v uint8) {
+	if b := w.reserve(1); b != nil {
+		b.WriteUint8(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt8(v int8) {
+	if b := w.reserve(1); b != nil {
+		b.WriteInt8(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint16(v uint16) {
+	if b := w.reserve(2); b != nil {
+		b.WriteUint16(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt16(v int16) {
+	if b := w.reserve(2); b != nil {
+		b.WriteInt16(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint16LE(v uint16) {
+	if b := w.reserve(2); b != nil {
+		b.WriteUint16LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt16LE(v int16) {
+	if b := w.reserve(2); b != nil {
+		b.WriteInt16LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint24(v uint32) {
+	if b := w.reserve(3); b != nil {
+		b.WriteUint24(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt24(v int32) {
+	if b := w.reserve(3); b != nil {
+		b.WriteInt24(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint24LE(v uint32) {
+	if b := w.reserve(3); b != nil {
+		b.WriteUint24LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt24LE(v int32) {
+	if b := w.reserve(3); b != nil {
+		b.WriteInt24LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint32(v uint32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteUint32(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt32(v int32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteInt32(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint32LE(v uint32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteUint32LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt32LE(v int32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteInt32LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint32V1(v uint32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteUint32V1(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt32V1(v int32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteInt32V1(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint32V2(v uint32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteUint32V2(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt32V2(v int32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteInt32V2(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint40(v uint64) {
+	if b := w.reserve(5); b != nil {
+		b.WriteUint40(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint40LE(v uint64) {
+	if b := w.reserve(5); b != nil {
+		b.WriteUint40LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt40(v int64) {
+	if b := w.reserve(5); b != nil {
+		b.WriteInt40(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt40LE(v int64) {
+	if b := w.reserve(5); b != nil {
+		b.WriteInt40LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint48(v uint64) {
+	if b := w.reserve(6); b != nil {
+		b.WriteUint48(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint48LE(v uint64) {
+	if b := w.reserve(6); b != nil {
+		b.WriteUint48LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt48(v int64) {
+	if b := w.reserve(6); b != nil {
+		b.WriteInt48(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt48LE(v int64) {
+	if b := w.reserve(6); b != nil {
+		b.WriteInt48LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint56(v uint64) {
+	if b := w.reserve(7); b != nil {
+		b.WriteUint56(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint56LE(v uint64) {
+	if b := w.reserve(7); b != nil {
+		b.WriteUint56LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt56(v int64) {
+	if b := w.reserve(7); b != nil {
+		b.WriteInt56(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt56LE(v int64) {
+	if b := w.reserve(7); b != nil {
+		b.WriteInt56LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint64(v uint64) {
+	if b := w.reserve(8); b != nil {
+		b.WriteUint64(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt64(v int64) {
+	if b := w.reserve(8); b != nil {
+		b.WriteInt64(v)
+	}
+}
+
+func (w *StreamWriter) WriteUint64LE(v uint64) {
+	if b := w.reserve(8); b != nil {
+		b.WriteUint64LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteInt64LE(v int64) {
+	if b := w.reserve(8); b != nil {
+		b.WriteInt64LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteFloat32(v float32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteFloat32(v)
+	}
+}
+
+func (w *StreamWriter) WriteFloat32LE(v float32) {
+	if b := w.reserve(4); b != nil {
+		b.WriteFloat32LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteFloat64(v float64) {
+	if b := w.reserve(8); b != nil {
+		b.WriteFloat64(v)
+	}
+}
+
+func (w *StreamWriter) WriteFloat64LE(v float64) {
+	if b := w.reserve(8); b != nil {
+		b.WriteFloat64LE(v)
+	}
+}
+
+func (w *StreamWriter) WriteBool(v bool) {
+	if b := w.reserve(1); b != nil {
+		b.WriteBool(v)
+	}
+}
+
+func (w *StreamWriter) WriteSmart(v uint16) {
+	if b := w.reserve(2); b != nil {
+		b.WriteSmart(v)
+	}
+}
+
+func (w *StreamWriter) WriteSignedSmart(v int16) {
+	if b := w.reserve(2); b != nil {
+		b.WriteSignedSmart(v)
+	}
+}
+
+func (w *StreamWriter) WriteVarInt(v uint64) {
+	if b := w.reserve(VarIntSize(v)); b != nil {
+		b.WriteVarInt(v)
+	}
+}
+
+func (w *StreamWriter) WriteSignedVarInt(v int64) {
+	w.WriteVarInt(zigzag(v))
+}
+
+func (w *StreamWriter) WriteUintN(n int, v uint64, o Order, t Transform) {
+	checkLayout(n, o, t)
+
+	if b := w.reserve(n); b != nil {
+		b.WriteUintN(n, v, o, t)
+	}
+}
+
+func (w *StreamWriter) WriteIntN(n int, v int64, o Order, t Transform) {
+	checkLayout(n, o, t)
+
+	if b := w.reserve(n); b != nil {
+		b.WriteIntN(n, v, o, t)
+	}
+}
+
+func (w *StreamWriter) WriteUint8s(src []uint8, t Transform) {
+	checkLayout(1, BigEndian, t)
+
+	writeChunks(w, src, 1, func(b *Buffer, chunk []uint8) {
+		b.WriteUint8s(chunk, t)
+	})
+}
+
+func (w *StreamWriter) WriteInt8s(src []int8, t Transform) {
+	checkLayout(1, BigEndian, t)
+
+	writeChunks(w, src, 1, func(b *Buffer, chunk []int8) {
+		b.WriteInt8s(chunk, t)
+	})
+}
+
+func (w *StreamWriter) WriteUint16s(src []uint16, o Order, t Transform) {
+	checkLayout(2, o, t)
+
+	writeChunks(w, src, 2, func(b *Buffer, chunk []uint16) {
+		b.WriteUint16s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteInt16s(src []int16, o Order, t Transform) {
+	checkLayout(2, o, t)
+
+	writeChunks(w, src, 2, func(b *Buffer, chunk []int16) {
+		b.WriteInt16s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteUint24s(src []uint32, o Order, t Transform) {
+	checkLayout(3, o, t)
+
+	writeChunks(w, src, 3, func(b *Buffer, chunk []uint32) {
+		b.WriteUint24s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteInt24s(src []int32, o Order, t Transform) {
+	checkLayout(3, o, t)
+
+	writeChunks(w, src, 3, func(b *Buffer, chunk []int32) {
+		b.WriteInt24s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteUint32s(src []uint32, o Order, t Transform) {
+	checkLayout(4, o, t)
+
+	writeChunks(w, src, 4, func(b *Buffer, chunk []uint32) {
+		b.WriteUint32s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteInt32s(src []int32, o Order, t Transform) {
+	checkLayout(4, o, t)
+
+	writeChunks(w, src, 4, func(b *Buffer, chunk []int32) {
+		b.WriteInt32s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteUint64s(src []uint64, o Order, t Transform) {
+	checkLayout(8, o, t)
+
+	writeChunks(w, src, 8, func(b *Buffer, chunk []uint64) {
+		b.WriteUint64s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteInt64s(src []int64, o Order, t Transform) {
+	checkLayout(8, o, t)
+
+	writeChunks(w, src, 8, func(b *Buffer, chunk []int64) {
+		b.WriteInt64s(chunk, o, t)
+	})
+}
+
+func (w *StreamWriter) WriteBytesAdd(src []byte) {
+	writeChunks(w, src, 1, (*Buffer).WriteBytesAdd)
+}
+
+func (w *StreamWriter) WriteBytesReversed(src []byte) {
+	writeChunksReversed(w, src, (*Buffer).WriteBytesReversed)
+}
+
+func (w *StreamWriter) WriteBytesReversedAdd(src []byte) {
+	writeChunksReversed(w, src, (*Buffer).WriteBytesReversedAdd)
+}
+
+// WriteCount writes the count n encoded as p. It panics if n cannot be
+// encoded as p.
+func (w *StreamWriter) WriteCount(p Prefix, n int) {
+	writeCount(w, p, n)
+}
+
+func (w *StreamWriter) WriteBytesPrefixed(p Prefix, data []byte) {
+	w.WriteCount(p, len(data))
+	w.Write(data)
+}
+
+// WriteString writes s followed by a 0 terminator.
+func (w *StreamWriter) WriteString(s string) {
+	for len(s) > 0 {
+		b := w.reserve(1)
+		if b == nil {
+			return
+		}
+
+		n := copy(b.data[b.writeIndex:], s)
+		b.writeIndex += n
+		s = s[n:]
+	}
+
+	w.WriteUint8(0)
+}
+
+// WriteJagString writes s in the format read by ReadJagString, a 0 byte
+// followed by the 0 terminated string.
+func (w *StreamWriter) WriteJagString(s string) {
+	w.WriteVersionedString(0, s)
+}
+
+func (w *StreamWriter) WriteVersionedString(version uint8, s string) {
+	w.WriteUint8(version)
+	w.WriteString(s)
+}
+
+func (w *StreamWriter) WriteNullableString(s *string) {
+	if s == nil {
+		w.WriteUint8(0)
+	} else {
+		w.WriteString(*s)
+	}
+}
+
+func (w *StreamWriter) WriteCESU8String(s string) {
+	data := appendModifiedUTF8(nil, s, false)
+
+	w.WriteUint8(0)
+	w.WriteVarInt(uint64(len(data)))
+	w.Write(data)
+}
+
+func (w *StreamWriter) WriteChar(v uint16) {
+	w.WriteUint16(v)
+}
+
+func (w *StreamWriter) WriteChars(s string) {
+	w.WriteUint16s(utf16.Encode([]rune(s)), BigEndian, TransformNone)
+}
+
+func (w *StreamWriter) WriteUTF(s string) {
+	data := appendModifiedUTF8(nil, s, true)
+	if len(data) > MaxUTFLen {
+		panic(fmt.Sprintf("jagbuf: UTF string of %d bytes too long", len(data)))
+	}
+
+	w.WriteUint16(uint16(len(data)))
+	w.Write(data)
+}
+
+func (w *StreamWriter) WriteBase37(name string) {
+	w.WriteUint64(EncodeBase37(name))
+}
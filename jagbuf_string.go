@@ -1,9 +1,9 @@
 package jagbuf
 
 import (
-	"errors"
+	"bytes"
+	"fmt"
 	"io"
-	"strings"
 )
 
 func (b *Buffer) ReadString() (string, error) {
@@ -11,26 +11,155 @@ func (b *Buffer) ReadString() (string, error) {
 		return "", io.EOF
 	}
 
-	builder := &strings.Builder{}
-	for i := 0; b.data[b.readIndex+i] != 0; i++ {
-		builder.WriteByte(b.data[b.readIndex+i])
+	readable := b.data[b.readIndex:b.writeIndex]
+
+	end := bytes.IndexByte(readable, 0)
+	if end < 0 {
+		return "", io.EOF
 	}
 
-	defer func() { b.readIndex += builder.Len() + 1 }()
-	return builder.String(), nil
+	b.readIndex += end + 1
+	return string(readable[:end]), nil
+}
+
+// VersionError is returned when the version byte in front of a string is
+// not the one expected.
+type VersionError struct {
+	Expected uint8
+	Actual   uint8
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("jagbuf: unexpected string version %d, expected %d", e.Actual, e.Expected)
 }
 
+func checkVersion(expected, actual uint8) error {
+	if actual != expected {
+		return &VersionError{Expected: expected, Actual: actual}
+	}
+	return nil
+}
+
+// ReadJagString reads a 0 terminated string with a version byte of 0 in
+// front of it, failing with a *VersionError for any other version.
 func (b *Buffer) ReadJagString() (string, error) {
-	if b.ReadableBytes() < 1 {
-		return "", io.EOF
+	return b.ReadVersionedString(0)
+}
+
+// ReadVersionedString reads a 0 terminated string with a version byte in
+// front of it, failing with a *VersionError if the version is not the one
+// given. Nothing is consumed unless the whole string is read.
+func (b *Buffer) ReadVersionedString(version uint8) (string, error) {
+	actual, err := b.PeekUint8()
+	if err != nil {
+		return "", err
+	}
+
+	if err := checkVersion(version, actual); err != nil {
+		return "", err
 	}
 
-	peek := b.data[b.readIndex]
 	b.readIndex++
+	str, err := b.ReadString()
+	if err != nil {
+		b.readIndex--
+	}
+	return str, err
+}
+
+// ReadNullableString reads a 0 terminated string, or nil if it is empty.
+// This is how the client sends an optional string, so an empty string
+// cannot be told apart from a missing one.
+func (b *Buffer) ReadNullableString() (*string, error) {
+	str, err := b.ReadString()
+	if err != nil || str == "" {
+		return nil, err
+	}
+	return &str, nil
+}
+
+// ReadCESU8String reads a string with a version byte of 0, followed by a
+// varint byte length and the string encoded as CESU-8. An unexpected version
+// fails with a *VersionError and malformed data with an error wrapping
+// ErrInvalidUTF. Nothing is consumed unless the whole string is read.
+func (b *Buffer) ReadCESU8String() (string, error) {
+	start := b.readIndex
+
+	str, err := b.readCESU8String()
+	if err != nil {
+		b.readIndex = start
+	}
+	return str, err
+}
 
-	if peek != 0 {
-		return "", errors.New("jagstring read: expected byte to be 0 in position 0")
+func (b *Buffer) readCESU8String() (string, error) {
+	version, err := b.ReadUint8()
+	if err != nil {
+		return "", err
 	}
 
-	return b.ReadString()
+	if err := checkVersion(0, version); err != nil {
+		return "", err
+	}
+
+	n, err := b.ReadVarInt()
+	if err != nil {
+		return "", err
+	}
+
+	if n > uint64(b.ReadableBytes()) {
+		return "", io.EOF
+	}
+
+	str, err := decodeModifiedUTF8(b.data[b.readIndex : b.readIndex+int(n)])
+	if err != nil {
+		return "", err
+	}
+
+	b.readIndex += int(n)
+	return str, nil
+}
+
+// WriteString writes s followed by a 0 terminator.
+func (b *Buffer) WriteString(s string) {
+	b.ensureWritable(len(s) + 1)
+
+	copy(b.data[b.writeIndex:], s)
+	b.data[b.writeIndex+len(s)] = 0
+
+	b.writeIndex += len(s) + 1
+}
+
+// WriteJagString writes s in the format read by ReadJagString, a 0 byte
+// followed by the 0 terminated string.
+func (b *Buffer) WriteJagString(s string) {
+	b.WriteVersionedString(0, s)
+}
+
+// WriteVersionedString writes the version byte followed by the 0
+// terminated string.
+func (b *Buffer) WriteVersionedString(version uint8, s string) {
+	b.WriteUint8(version)
+	b.WriteString(s)
+}
+
+// WriteNullableString writes s in the format read by ReadNullableString,
+// with nil written as an empty string.
+func (b *Buffer) WriteNullableString(s *string) {
+	if s == nil {
+		b.WriteUint8(0)
+	} else {
+		b.WriteString(*s)
+	}
+}
+
+// WriteCESU8String writes s in the format read by ReadCESU8String.
+func (b *Buffer) WriteCESU8String(s string) {
+	n := modifiedUTF8Len(s, false)
+
+	b.WriteUint8(0)
+	b.WriteVarInt(uint64(n))
+	b.ensureWritable(n)
+	appendModifiedUTF8(b.data[b.writeIndex:b.writeIndex], s, false)
+	b.writeIndex += n
 }
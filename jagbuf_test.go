@@ -2,7 +2,10 @@ package jagbuf
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"testing"
 )
 
@@ -209,3 +212,586 @@ func TestBuffer_WriteInt32(t *testing.T) {
 		fmt.Printf("WriteInt32 fail: Expected -32768 but received %d", val)
 	}
 }
+
+func TestBuffer_GrowKeepsData(t *testing.T) {
+	buffer := NewWithCapacity(2)
+
+	buffer.WriteUint16(0x1020)
+	buffer.WriteUint32(0x30405060)
+
+	expected := []byte{0x10, 0x20, 0x30, 0x40, 0x50, 0x60}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("Grow fail: expected %v after growing, got %v", expected, buffer.Bytes())
+	}
+}
+
+func TestBuffer_ReadStopsAtWriteIndex(t *testing.T) {
+	buffer := NewWithCapacity(64)
+	buffer.WriteUint16(0x1020)
+
+	dst := make([]byte, 8)
+	n, err := buffer.Read(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n != 2 {
+		t.Errorf("Read fail: expected 2 bytes, read %d", n)
+	}
+}
+
+func TestBuffer_ReadString_Unterminated(t *testing.T) {
+	buffer := Wrap([]byte("hello"))
+
+	if _, err := buffer.ReadString(); err == nil {
+		t.Error("ReadString fail: expected an error for an unterminated string")
+	}
+}
+
+func TestBuffer_ReadUint16_Sub_LowByte(t *testing.T) {
+	// Only the low byte is transformed, so a low byte below 0x80 must not
+	// borrow from the high byte.
+	if val, _ := Wrap([]byte{0x12, 0x05}).ReadUint16_Sub(); val != 0x1285 {
+		t.Errorf("ReadUint16_Sub fail: expected 0x1285, got 0x%x", val)
+	}
+	if val, _ := Wrap([]byte{0x05, 0x12}).ReadUint16LE_Sub(); val != 0x1285 {
+		t.Errorf("ReadUint16LE_Sub fail: expected 0x1285, got 0x%x", val)
+	}
+}
+
+func TestBuffer_ReadWriteUint32V1V2(t *testing.T) {
+	buffer := NewWithCapacity(64)
+
+	buffer.WriteUint32V1(0x10203040)
+	buffer.WriteUint32V2(0x10203040)
+
+	expected := []byte{0x30, 0x40, 0x10, 0x20, 0x20, 0x10, 0x40, 0x30}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("WriteUint32V1/V2 fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	v1, err := buffer.ReadUint32V1()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2, err := buffer.ReadUint32V2()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if v1 != 0x10203040 || v2 != 0x10203040 {
+		t.Errorf("ReadUint32V1/V2 fail: expected 0x10203040, got 0x%x and 0x%x", v1, v2)
+	}
+}
+
+func TestBuffer_ReadWriteUintN(t *testing.T) {
+	buffer := NewWithCapacity(64)
+
+	buffer.WriteUintN(2, 0x1020, LittleEndian, TransformAdd)
+	buffer.WriteUintN(4, 0x10203040, MiddleEndianV2, TransformNone)
+	buffer.WriteIntN(3, -2, BigEndian, TransformNone)
+
+	expected := []byte{0xA0, 0x10, 0x20, 0x10, 0x40, 0x30, 0xFF, 0xFF, 0xFE}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Fatalf("WriteUintN fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	le, err := buffer.ReadUint16LE_Sub()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v2, err := buffer.ReadUintN(4, MiddleEndianV2, TransformNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	neg, err := buffer.ReadIntN(3, BigEndian, TransformNone)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if le != 0x1020 || v2 != 0x10203040 || neg != -2 {
+		t.Errorf("ReadUintN fail: got 0x%x, 0x%x, %d", le, v2, neg)
+	}
+}
+
+func TestBuffer_ReadWriteBulk(t *testing.T) {
+	buffer := NewWithCapacity(8)
+
+	src := []int32{-1, 0, 8388607, -8388608}
+	buffer.WriteInt24s(src, LittleEndian, TransformNeg)
+
+	dst := make([]int32, len(src))
+	if err := buffer.ReadInt24s(dst, LittleEndian, TransformNeg); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := range src {
+		if src[i] != dst[i] {
+			t.Errorf("ReadInt24s fail: expected %v, got %v", src, dst)
+			break
+		}
+	}
+
+	if err := buffer.ReadUint16s(make([]uint16, 1), BigEndian, TransformNone); err == nil {
+		t.Error("ReadUint16s fail: expected io.EOF reading past the written data")
+	}
+}
+
+func TestBuffer_ReadWriteBytesReversedAdd(t *testing.T) {
+	buffer := NewWithCapacity(64)
+
+	buffer.WriteBytesReversedAdd([]byte{0x01, 0x02, 0x03})
+
+	expected := []byte{0x83, 0x82, 0x81}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Fatalf("WriteBytesReversedAdd fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	dst := make([]byte, 3)
+	if err := buffer.ReadBytesReversedAdd(dst); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(dst, []byte{0x01, 0x02, 0x03}) {
+		t.Errorf("ReadBytesReversedAdd fail: expected [1 2 3], got %v", dst)
+	}
+}
+
+func TestDecoder_StickyError(t *testing.T) {
+	buffer := NewWithCapacity(64)
+	buffer.WriteUint16(0x1020)
+	buffer.WriteUint8(0x30)
+
+	decoder := NewDecoder(buffer)
+
+	if val := decoder.ReadUint16(); val != 0x1020 {
+		t.Errorf("Decoder fail: expected 0x1020, got 0x%x", val)
+	}
+
+	if val := decoder.ReadUint16(); val != 0 {
+		t.Errorf("Decoder fail: expected 0 from a failed read, got 0x%x", val)
+	}
+
+	if val := decoder.ReadUint8(); val != 0 {
+		t.Errorf("Decoder fail: expected 0 after an error, got 0x%x", val)
+	}
+
+	if decoder.Err() != io.EOF {
+		t.Errorf("Decoder fail: expected io.EOF, got %v", decoder.Err())
+	}
+
+	if buffer.ReadableBytes() != 1 {
+		t.Errorf("Decoder fail: read from the buffer after an error")
+	}
+}
+
+func TestBuffer_ReadWriteVarInt(t *testing.T) {
+	tests := []struct {
+		v        uint64
+		expected []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x81, 0x00}},
+		{0x3FFF, []byte{0xFF, 0x7F}},
+		{0x4000, []byte{0x81, 0x80, 0x00}},
+		{math.MaxUint64, []byte{0x81, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F}},
+	}
+
+	for _, test := range tests {
+		buffer := NewBuffer()
+		buffer.WriteVarInt(test.v)
+
+		if !bytes.Equal(buffer.Bytes(), test.expected) || VarIntSize(test.v) != len(test.expected) {
+			t.Errorf("WriteVarInt fail: expected %v, got %v", test.expected, buffer.Bytes())
+		}
+
+		val, err := buffer.ReadVarInt()
+		if err != nil || val != test.v {
+			t.Errorf("ReadVarInt fail: expected %d, got %d (%v)", test.v, val, err)
+		}
+	}
+}
+
+func TestBuffer_ReadWriteSignedVarInt(t *testing.T) {
+	for _, v := range []int64{0, -1, 1, -64, 63, -65, 64, math.MinInt64, math.MaxInt64} {
+		buffer := NewBuffer()
+		buffer.WriteSignedVarInt(v)
+
+		if buffer.ReadableBytes() != SignedVarIntSize(v) {
+			t.Errorf("SignedVarIntSize fail: expected %d for %d, got %d", buffer.ReadableBytes(), v, SignedVarIntSize(v))
+		}
+
+		val, err := buffer.ReadSignedVarInt()
+		if err != nil || val != v {
+			t.Errorf("ReadSignedVarInt fail: expected %d, got %d (%v)", v, val, err)
+		}
+	}
+
+	if size := SignedVarIntSize(-64); size != 1 {
+		t.Errorf("SignedVarIntSize fail: expected -64 to take 1 byte, got %d", size)
+	}
+}
+
+func TestBuffer_ReadVarInt_Invalid(t *testing.T) {
+	buffer := Wrap([]byte{0x81, 0x80})
+	if _, err := buffer.ReadVarInt(); err != io.EOF || buffer.ReadableBytes() != 2 {
+		t.Errorf("ReadVarInt fail: expected io.EOF with nothing consumed, got %v", err)
+	}
+
+	buffer = Wrap([]byte{0x82, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0x7F})
+	if _, err := buffer.ReadVarInt(); err == nil {
+		t.Errorf("ReadVarInt fail: expected an overflow error")
+	}
+
+	// Leading zero groups never overflow the value, but are still limited
+	// to MaxVarIntLen bytes.
+	padded := append(bytes.Repeat([]byte{0x80}, MaxVarIntLen), 0x01)
+	if _, err := Wrap(padded).ReadVarInt(); err != errVarIntOverflow {
+		t.Errorf("ReadVarInt fail: expected an overflow error, got %v", err)
+	}
+	if _, err := NewStreamReader(bytes.NewReader(padded)).ReadVarInt(); err != errVarIntOverflow {
+		t.Errorf("StreamReader fail: expected an overflow error, got %v", err)
+	}
+}
+
+func TestBuffer_ReadWriteFloat(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteFloat32(1.5)
+	buffer.WriteFloat32LE(-0.25)
+	buffer.WriteFloat64(math.Pi)
+	buffer.WriteFloat64LE(math.Inf(-1))
+
+	expected := []byte{0x3F, 0xC0, 0, 0, 0, 0, 0x80, 0xBE}
+	if !bytes.Equal(buffer.Bytes()[:8], expected) {
+		t.Errorf("WriteFloat32 fail: expected %v, got %v", expected, buffer.Bytes()[:8])
+	}
+
+	f32, _ := buffer.ReadFloat32()
+	f32LE, _ := buffer.ReadFloat32LE()
+	f64, _ := buffer.ReadFloat64()
+	f64LE, err := buffer.ReadFloat64LE()
+	if err != nil || f32 != 1.5 || f32LE != -0.25 || f64 != math.Pi || !math.IsInf(f64LE, -1) {
+		t.Errorf("ReadFloat fail: got %v, %v, %v, %v (%v)", f32, f32LE, f64, f64LE, err)
+	}
+}
+
+func TestBuffer_ReadWriteBool(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteBool(true)
+	buffer.WriteBool(false)
+	buffer.WriteUint8(2)
+
+	if !bytes.Equal(buffer.Bytes(), []byte{1, 0, 2}) {
+		t.Errorf("WriteBool fail: expected [1 0 2], got %v", buffer.Bytes())
+	}
+
+	first, _ := buffer.ReadBoolStrict()
+	second, _ := buffer.ReadBoolStrict()
+	if !first || second {
+		t.Errorf("ReadBoolStrict fail: expected true and false, got %t and %t", first, second)
+	}
+
+	if _, err := buffer.ReadBoolStrict(); !errors.Is(err, ErrInvalidBool) || buffer.ReadableBytes() != 1 {
+		t.Errorf("ReadBoolStrict fail: expected ErrInvalidBool with nothing consumed, got %v", err)
+	}
+
+	if val, err := buffer.ReadBool(); !val || err != nil {
+		t.Errorf("ReadBool fail: expected true, got %t (%v)", val, err)
+	}
+}
+
+func TestBuffer_ReadWriteWideInts(t *testing.T) {
+	const v = 0x8877665544332211
+
+	buffer := NewBuffer()
+	buffer.WriteUint40(v)
+	buffer.WriteInt40LE(-2)
+	buffer.WriteUint48LE(v)
+	buffer.WriteInt48(-2)
+	buffer.WriteUint56(v)
+	buffer.WriteInt56LE(-2)
+
+	expected := NewBuffer()
+	expected.WriteUintN(5, v, BigEndian, TransformNone)
+	expected.WriteIntN(5, -2, LittleEndian, TransformNone)
+	expected.WriteUintN(6, v, LittleEndian, TransformNone)
+	expected.WriteIntN(6, -2, BigEndian, TransformNone)
+	expected.WriteUintN(7, v, BigEndian, TransformNone)
+	expected.WriteIntN(7, -2, LittleEndian, TransformNone)
+
+	if !bytes.Equal(buffer.Bytes(), expected.Bytes()) {
+		t.Fatalf("WriteUint40/48/56 fail: expected %v, got %v", expected.Bytes(), buffer.Bytes())
+	}
+
+	u40, _ := buffer.ReadUint40()
+	i40, _ := buffer.ReadInt40LE()
+	u48, _ := buffer.ReadUint48LE()
+	i48, _ := buffer.ReadInt48()
+	u56, _ := buffer.ReadUint56()
+	i56, err := buffer.ReadInt56LE()
+	if err != nil || u40 != 0x5544332211 || u48 != 0x665544332211 || u56 != 0x77665544332211 {
+		t.Errorf("ReadUint40/48/56 fail: got 0x%x, 0x%x, 0x%x (%v)", u40, u48, u56, err)
+	}
+	if i40 != -2 || i48 != -2 || i56 != -2 {
+		t.Errorf("ReadInt40/48/56 fail: expected -2, got %d, %d, %d", i40, i48, i56)
+	}
+
+	if _, err := Wrap(make([]byte, 5)).ReadUint48(); err != io.EOF {
+		t.Errorf("ReadUint48 fail: expected io.EOF, got %v", err)
+	}
+}
+
+func TestBuffer_Peek(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteUint16LE(0x1234)
+	buffer.WriteSmart(300)
+	buffer.WriteString("hello")
+
+	u16, _ := buffer.PeekUint16LE()
+	u8, _ := buffer.PeekUint8_Sub()
+	n, _ := buffer.PeekUintN(2, LittleEndian, TransformNone)
+	if u16 != 0x1234 || u8 != 0x34+128 || n != 0x1234 || buffer.ReadableBytes() != 10 {
+		t.Errorf("Peek fail: got 0x%x, 0x%x, 0x%x with %d bytes readable", u16, u8, n, buffer.ReadableBytes())
+	}
+
+	header := make([]byte, 2)
+	if err := buffer.PeekBytes(header); err != nil || !bytes.Equal(header, []byte{0x34, 0x12}) {
+		t.Errorf("PeekBytes fail: expected [52 18], got %v (%v)", header, err)
+	}
+
+	_, _ = buffer.ReadUint16LE()
+	if smart, _ := buffer.PeekSmart(); smart != 300 {
+		t.Errorf("PeekSmart fail: expected 300, got %d", smart)
+	}
+
+	_, _ = buffer.ReadSmart()
+	if str, _ := buffer.PeekString(); str != "hello" {
+		t.Errorf("PeekString fail: expected \"hello\", got %q", str)
+	}
+
+	if str, _ := buffer.ReadString(); str != "hello" {
+		t.Errorf("ReadString fail: expected \"hello\" after peeking, got %q", str)
+	}
+
+	if _, err := buffer.PeekUint8(); err != io.EOF {
+		t.Errorf("PeekUint8 fail: expected io.EOF, got %v", err)
+	}
+}
+
+func TestBuffer_ReadWriteUTF(t *testing.T) {
+	// As written by DataOutputStream.writeUTF("a\u0000é€😀").
+	expected := []byte{
+		0x00, 0x0E,
+		0x61,
+		0xC0, 0x80,
+		0xC3, 0xA9,
+		0xE2, 0x82, 0xAC,
+		0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80,
+	}
+
+	buffer := NewBuffer()
+	buffer.WriteUTF("a\x00é€😀")
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("WriteUTF fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	str, err := buffer.ReadUTF()
+	if err != nil || str != "a\x00é€😀" {
+		t.Errorf("ReadUTF fail: expected %q, got %q (%v)", "a\x00é€😀", str, err)
+	}
+
+	buffer = Wrap([]byte{0x00, 0x02, 0xC3, 0x41})
+	if _, err := buffer.ReadUTF(); !errors.Is(err, ErrInvalidUTF) || buffer.ReadableBytes() != 4 {
+		t.Errorf("ReadUTF fail: expected ErrInvalidUTF with nothing consumed, got %v", err)
+	}
+
+	buffer = Wrap([]byte{0x00, 0x03, 0x41})
+	if _, err := buffer.ReadUTF(); err != io.EOF {
+		t.Errorf("ReadUTF fail: expected io.EOF, got %v", err)
+	}
+}
+
+func TestBuffer_ReadWriteChars(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteChars("a😀")
+	buffer.WriteChar('b')
+
+	expected := []byte{0x00, 0x61, 0xD8, 0x3D, 0xDE, 0x00, 0x00, 0x62}
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("WriteChars fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	buffer.Skip(6)
+	if c, err := buffer.ReadChar(); c != 'b' || err != nil {
+		t.Errorf("ReadChar fail: expected 'b', got %q (%v)", c, err)
+	}
+}
+
+func TestBuffer_ReadJagString_BadVersion(t *testing.T) {
+	buffer := Wrap([]byte{0x1, 'h', 'i', 0x0})
+
+	_, err := buffer.ReadJagString()
+
+	var versionErr *VersionError
+	if !errors.As(err, &versionErr) || versionErr.Expected != 0 || versionErr.Actual != 1 {
+		t.Errorf("ReadJagString fail: expected a VersionError for version 1, got %v", err)
+	}
+	if buffer.ReadableBytes() != 4 {
+		t.Errorf("ReadJagString fail: expected nothing consumed, %d bytes left", buffer.ReadableBytes())
+	}
+
+	if str, err := buffer.ReadVersionedString(1); str != "hi" || err != nil {
+		t.Errorf("ReadVersionedString fail: expected \"hi\", got %q (%v)", str, err)
+	}
+}
+
+func TestBuffer_ReadVersionedString_Unterminated(t *testing.T) {
+	buffer := Wrap([]byte{0x2, 'h', 'i'})
+
+	if _, err := buffer.ReadVersionedString(2); err != io.EOF || buffer.ReadableBytes() != 3 {
+		t.Errorf("ReadVersionedString fail: expected io.EOF with nothing consumed, got %v", err)
+	}
+}
+
+func TestBuffer_ReadWriteNullableString(t *testing.T) {
+	hello := "hello"
+
+	buffer := NewBuffer()
+	buffer.WriteNullableString(nil)
+	buffer.WriteNullableString(&hello)
+
+	if !bytes.Equal(buffer.Bytes(), []byte("\x00hello\x00")) {
+		t.Errorf("WriteNullableString fail: expected %v, got %v", []byte("\x00hello\x00"), buffer.Bytes())
+	}
+
+	null, err := buffer.ReadNullableString()
+	if null != nil || err != nil {
+		t.Errorf("ReadNullableString fail: expected nil, got %v (%v)", null, err)
+	}
+
+	str, err := buffer.ReadNullableString()
+	if str == nil || *str != hello || err != nil {
+		t.Errorf("ReadNullableString fail: expected %q, got %v (%v)", hello, str, err)
+	}
+}
+
+func TestBuffer_ReadWriteCESU8String(t *testing.T) {
+	expected := []byte{0x00, 0x09, 0x00, 'a', 0xED, 0xA0, 0xBD, 0xED, 0xB8, 0x80, 'b'}
+
+	buffer := NewBuffer()
+	buffer.WriteCESU8String("\x00a😀b")
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("WriteCESU8String fail: expected %v, got %v", expected, buffer.Bytes())
+	}
+
+	str, err := buffer.ReadCESU8String()
+	if str != "\x00a😀b" || err != nil {
+		t.Errorf("ReadCESU8String fail: expected %q, got %q (%v)", "\x00a😀b", str, err)
+	}
+
+	for _, data := range [][]byte{{0x00, 0x05, 'a'}, {0x00, 0x81}} {
+		buffer = Wrap(data)
+		if _, err := buffer.ReadCESU8String(); err != io.EOF || buffer.ReadableBytes() != len(data) {
+			t.Errorf("ReadCESU8String fail: expected io.EOF with nothing consumed for %v, got %v", data, err)
+		}
+	}
+
+	var versionErr *VersionError
+	if _, err := Wrap([]byte{0x01, 0x00}).ReadCESU8String(); !errors.As(err, &versionErr) {
+		t.Errorf("ReadCESU8String fail: expected a VersionError, got %v", err)
+	}
+}
+
+func TestBase37(t *testing.T) {
+	tests := []struct {
+		name       string
+		encoded    uint64
+		normalized string
+	}{
+		{"a", 1, "a"},
+		{"_", 0, ""},
+		{"Zezima", 0x6C1A00CC, "zezima"},
+		{"mod ash", 0x802866894, "mod_ash"},
+		{"  spaced  ", EncodeBase37("spaced"), "spaced"},
+		{"9999999999999", 0x5B5B57F8A98A5DD0, "999999999999"},
+		{"a-b!c", EncodeBase37("a_b_c"), "a_b_c"},
+		{"zoë 1", EncodeBase37("zo__1"), "zo__1"},
+		{"abcdefghijké", EncodeBase37("abcdefghijk"), "abcdefghijk"},
+		{"éééééééééééab", 1, "a"},
+		{"a😀b", EncodeBase37("a__b"), "a__b"},
+	}
+
+	for _, test := range tests {
+		encoded := EncodeBase37(test.name)
+		if encoded != test.encoded {
+			t.Errorf("EncodeBase37 fail: expected 0x%x for %q, got 0x%x", test.encoded, test.name, encoded)
+		}
+
+		if decoded := DecodeBase37(encoded); decoded != test.normalized {
+			t.Errorf("DecodeBase37 fail: expected %q, got %q", test.normalized, decoded)
+		}
+
+		if normalized := NormalizeBase37(test.name); normalized != test.normalized {
+			t.Errorf("NormalizeBase37 fail: expected %q, got %q", test.normalized, normalized)
+		}
+	}
+
+	for _, v := range []uint64{0, 37, 0x5B5B57F8A98A5DD1, math.MaxUint64} {
+		if decoded := DecodeBase37(v); decoded != "" {
+			t.Errorf("DecodeBase37 fail: expected 0x%x to be invalid, got %q", v, decoded)
+		}
+	}
+}
+
+func TestBuffer_ReadWriteBase37(t *testing.T) {
+	buffer := NewBuffer()
+	buffer.WriteBase37("Mod Ash")
+	buffer.WriteUint64(37)
+
+	if name, err := buffer.ReadBase37(); name != "mod_ash" || err != nil {
+		t.Errorf("ReadBase37 fail: expected \"mod_ash\", got %q (%v)", name, err)
+	}
+
+	if _, err := buffer.ReadBase37(); err != ErrInvalidBase37 || buffer.ReadableBytes() != 8 {
+		t.Errorf("ReadBase37 fail: expected ErrInvalidBase37 with nothing consumed, got %v", err)
+	}
+}
+
+func TestNameHashes(t *testing.T) {
+	tests := []struct {
+		name string
+		jag  int32
+		js5  int32
+	}{
+		{"m50_50", -353242042, -1123920270},
+		{"l50_50", -1197838343, -1152549421},
+		{"huffman", 1579540093, 1258058669},
+		{"title.dat", -566502255, -2136890623},
+	}
+
+	for _, test := range tests {
+		if h := JagNameHash(test.name); h != test.jag {
+			t.Errorf("JagNameHash fail: expected %d for %q, got %d", test.jag, test.name, h)
+		}
+		if h := JS5NameHash(test.name); h != test.js5 {
+			t.Errorf("JS5NameHash fail: expected %d for %q, got %d", test.js5, test.name, h)
+		}
+	}
+
+	if JagNameHash("Title.DAT") != JagNameHash("title.dat") {
+		t.Errorf("JagNameHash fail: expected names to be case insensitive")
+	}
+
+	// € is 0x80 in Windows-1252, not its code point, and is hashed as the
+	// signed byte -128.
+	if h := JS5NameHash("€uro"); h != -3697166 {
+		t.Errorf("JS5NameHash fail: expected -3697166, got %d", h)
+	}
+	if h := JS5NameHash("café.dat"); h != -466522806 {
+		t.Errorf("JS5NameHash fail: expected -466522806, got %d", h)
+	}
+}
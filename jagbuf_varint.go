@@ -0,0 +1,79 @@
+package jagbuf
+
+import (
+	"errors"
+	"io"
+	"math/bits"
+)
+
+// MaxVarIntLen is the longest encoding of a varint.
+const MaxVarIntLen = 10
+
+var errVarIntOverflow = errors.New("jagbuf: varint overflows 64 bits")
+
+// ReadVarInt reads a variable length integer, stored 7 bits per byte with
+// the most significant group first and the high bit set on every byte but
+// the last.
+func (b *Buffer) ReadVarInt() (uint64, error) {
+	var v uint64
+	for i, c := range b.data[b.readIndex:b.writeIndex] {
+		if i == MaxVarIntLen || v>>57 != 0 {
+			return 0, errVarIntOverflow
+		}
+
+		v = v<<7 | uint64(c&0x7F)
+		if c < 0x80 {
+			b.readIndex += i + 1
+			return v, nil
+		}
+	}
+
+	return 0, io.EOF
+}
+
+// ReadSignedVarInt reads a variable length integer zig-zag encoded by
+// WriteSignedVarInt.
+func (b *Buffer) ReadSignedVarInt() (int64, error) {
+	v, err := b.ReadVarInt()
+	return unzigzag(v), err
+}
+
+// WriteVarInt writes v as a variable length integer of VarIntSize(v) bytes.
+func (b *Buffer) WriteVarInt(v uint64) {
+	n := VarIntSize(v)
+	b.ensureWritable(n)
+
+	s := b.data[b.writeIndex : b.writeIndex+n]
+	for i := n - 1; i >= 0; i-- {
+		s[i] = byte(v) | 0x80
+		v >>= 7
+	}
+	s[n-1] &= 0x7F
+
+	b.writeIndex += n
+}
+
+// WriteSignedVarInt writes v as a zig-zag encoded variable length integer,
+// so values close to zero are short whatever their sign.
+func (b *Buffer) WriteSignedVarInt(v int64) {
+	b.WriteVarInt(zigzag(v))
+}
+
+// VarIntSize returns the number of bytes WriteVarInt writes for v.
+func VarIntSize(v uint64) int {
+	return max(1, (bits.Len64(v)+6)/7)
+}
+
+// SignedVarIntSize returns the number of bytes WriteSignedVarInt writes
+// for v.
+func SignedVarIntSize(v int64) int {
+	return VarIntSize(zigzag(v))
+}
+
+func zigzag(v int64) uint64 {
+	return uint64(v<<1) ^ uint64(v>>63)
+}
+
+func unzigzag(v uint64) int64 {
+	return int64(v>>1) ^ -int64(v&1)
+}
@@ -0,0 +1,93 @@
+package jagbuf
+
+// Writer is the write side of the Buffer method set. It is implemented by
+// Buffer for in-memory data and by StreamWriter for output that is flushed
+// to an io.Writer as it is encoded.
+type Writer interface {
+	Write(data []byte) int
+
+	WriteUint8(v uint8)
+	WriteInt8(v int8)
+	WriteUint16(v uint16)
+	WriteInt16(v int16)
+	WriteUint16LE(v uint16)
+	WriteInt16LE(v int16)
+	WriteUint24(v uint32)
+	WriteInt24(v int32)
+	WriteUint24LE(v uint32)
+	WriteInt24LE(v int32)
+	WriteUint32(v uint32)
+	WriteInt32(v int32)
+	WriteUint32LE(v uint32)
+	WriteInt32LE(v int32)
+	WriteUint32V1(v uint32)
+	WriteInt32V1(v int32)
+	WriteUint32V2(v uint32)
+	WriteInt32V2(v int32)
+	WriteUint40(v uint64)
+	WriteInt40(v int64)
+	WriteUint40LE(v uint64)
+	WriteInt40LE(v int64)
+	WriteUint48(v uint64)
+	WriteInt48(v int64)
+	WriteUint48LE(v uint64)
+	WriteInt48LE(v int64)
+	WriteUint56(v uint64)
+	WriteInt56(v int64)
+	WriteUint56LE(v uint64)
+	WriteInt56LE(v int64)
+	WriteUint64(v uint64)
+	WriteInt64(v int64)
+	WriteUint64LE(v uint64)
+	WriteInt64LE(v int64)
+
+	WriteFloat32(v float32)
+	WriteFloat32LE(v float32)
+	WriteFloat64(v float64)
+	WriteFloat64LE(v float64)
+
+	WriteBool(v bool)
+
+	WriteSmart(v uint16)
+	WriteSignedSmart(v int16)
+	WriteVarInt(v uint64)
+	WriteSignedVarInt(v int64)
+
+	WriteUintN(n int, v uint64, o Order, t Transform)
+	WriteIntN(n int, v int64, o Order, t Transform)
+
+	WriteUint8s(src []uint8, t Transform)
+	WriteInt8s(src []int8, t Transform)
+	WriteUint16s(src []uint16, o Order, t Transform)
+	WriteInt16s(src []int16, o Order, t Transform)
+	WriteUint24s(src []uint32, o Order, t Transform)
+	WriteInt24s(src []int32, o Order, t Transform)
+	WriteUint32s(src []uint32, o Order, t Transform)
+	WriteInt32s(src []int32, o Order, t Transform)
+	WriteUint64s(src []uint64, o Order, t Transform)
+	WriteInt64s(src []int64, o Order, t Transform)
+
+	WriteBytesAdd(src []byte)
+	WriteBytesReversed(src []byte)
+	WriteBytesReversedAdd(src []byte)
+
+	WriteCount(p Prefix, n int)
+	WriteBytesPrefixed(p Prefix, data []byte)
+
+	WriteString(s string)
+	WriteJagString(s string)
+	WriteVersionedString(version uint8, s string)
+	WriteNullableString(s *string)
+	WriteCESU8String(s string)
+
+	WriteChar(v uint16)
+	WriteChars(s string)
+	WriteUTF(s string)
+
+	WriteBase37(name string)
+}
+
+var (
+	_ Writer = (*Buffer)(nil)
+	_ Writer = (*StreamWriter)(nil)
+)
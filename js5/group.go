@@ -0,0 +1,131 @@
+package js5
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+// MaxStripes is the most stripes a group can be split into.
+const MaxStripes = 0xFF
+
+var errGroupLayout = errors.New("js5: invalid group layout")
+
+// SplitGroup reads a group of fileCount files from the readable bytes of b.
+//
+// A group with a single file is just the file. Otherwise the files are
+// stored in stripes, each holding a chunk of every file in turn, followed
+// by the length of every chunk and then the number of stripes in the last
+// byte. The chunk lengths of each stripe are written as deltas from the one
+// before.
+func SplitGroup(b *jagbuf.Buffer, fileCount int) ([][]byte, error) {
+	if fileCount < 1 {
+		return nil, fmt.Errorf("js5: group of %d files", fileCount)
+	}
+
+	data := make([]byte, b.ReadableBytes())
+	_ = b.ReadBytes(data)
+
+	if fileCount == 1 {
+		return [][]byte{data}, nil
+	}
+
+	if len(data) < 1 {
+		return nil, fmt.Errorf("%w: no stripe count", errGroupLayout)
+	}
+
+	stripes := int(data[len(data)-1])
+	trailer := stripes * fileCount * 4
+	if trailer > len(data)-1 {
+		return nil, fmt.Errorf("%w: %d stripes of %d files in %d bytes", errGroupLayout, stripes, fileCount, len(data))
+	}
+
+	chunks := make([]int, stripes*fileCount)
+	lengths := make([]int, fileCount)
+	remaining := len(data) - 1 - trailer
+
+	d := jagbuf.NewDecoder(jagbuf.Wrap(data[remaining : len(data)-1]))
+	for stripe := range stripes {
+		chunk := 0
+		for file := range fileCount {
+			chunk += int(d.ReadInt32())
+			if chunk < 0 || chunk > remaining {
+				return nil, fmt.Errorf("%w: chunk of %d bytes", errGroupLayout, chunk)
+			}
+
+			chunks[stripe*fileCount+file] = chunk
+			lengths[file] += chunk
+			remaining -= chunk
+		}
+	}
+
+	if remaining != 0 {
+		return nil, fmt.Errorf("%w: %d bytes not in any file", errGroupLayout, remaining)
+	}
+
+	files := make([][]byte, fileCount)
+	for file, n := range lengths {
+		files[file] = make([]byte, 0, n)
+	}
+
+	pos := 0
+	for i, chunk := range chunks {
+		file := i % fileCount
+		files[file] = append(files[file], data[pos:pos+chunk]...)
+		pos += chunk
+	}
+
+	return files, nil
+}
+
+// JoinGroup returns files joined into a group in the format read by
+// SplitGroup, with each file split into the given number of stripes. A
+// single file is returned as it is. It panics if there are no files, or if
+// stripes is not between 1 and MaxStripes.
+func JoinGroup(files [][]byte, stripes int) []byte {
+	switch len(files) {
+	case 0:
+		panic("js5: group of 0 files")
+	case 1:
+		return files[0]
+	}
+
+	if stripes < 1 || stripes > MaxStripes {
+		panic(fmt.Sprintf("js5: %d stripes out of range", stripes))
+	}
+
+	size := 1 + stripes*len(files)*4
+	for _, f := range files {
+		size += len(f)
+	}
+
+	b := jagbuf.NewWithCapacity(size)
+	for stripe := range stripes {
+		for _, f := range files {
+			b.Write(stripeOf(f, stripe, stripes))
+		}
+	}
+
+	for stripe := range stripes {
+		prev := 0
+		for _, f := range files {
+			chunk := len(stripeOf(f, stripe, stripes))
+			b.WriteInt32(int32(chunk - prev))
+			prev = chunk
+		}
+	}
+
+	b.WriteUint8(uint8(stripes))
+	return b.Bytes()
+}
+
+// stripeOf returns the chunk of f stored in the given stripe, with f split
+// as evenly as possible and the last stripe taking what is left.
+func stripeOf(f []byte, stripe, stripes int) []byte {
+	n := len(f) / stripes
+	if stripe == stripes-1 {
+		return f[stripe*n:]
+	}
+	return f[stripe*n : (stripe+1)*n]
+}
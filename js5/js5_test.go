@@ -0,0 +1,315 @@
+package js5
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+func testTable(protocol uint8, flags Flags) *ReferenceTable {
+	t := &ReferenceTable{
+		Protocol: protocol,
+		Flags:    flags,
+		Groups: []Group{
+			{ID: 0, Checksum: 0xDEADBEEF, Version: 1, Files: []File{{ID: 0}}},
+			{ID: 3, Checksum: 0x12345678, Version: -2, Files: []File{{ID: 1}, {ID: 5}, {ID: 6}}},
+			{ID: 40000, Checksum: 1, Version: 3},
+		},
+	}
+
+	if protocol >= 6 {
+		t.Version = 1234
+	}
+	if protocol >= 7 {
+		t.Groups = append(t.Groups, Group{ID: 140000, Files: []File{{ID: 70000}}})
+	}
+
+	for i := range t.Groups {
+		g := &t.Groups[i]
+
+		if flags&FlagNamed != 0 {
+			g.NameHash = int32(i) * 1000003
+			for j := range g.Files {
+				g.Files[j].NameHash = int32(j) - 7
+			}
+		}
+		if flags&FlagUncompressedChecksums != 0 {
+			g.UncompressedChecksum = uint32(i) + 100
+		}
+		if flags&FlagDigests != 0 {
+			g.Digest[0], g.Digest[DigestLen-1] = byte(i), 0xFF
+		}
+		if flags&FlagLengths != 0 {
+			g.Length, g.UncompressedLength = uint32(i)*10, uint32(i)*20
+		}
+	}
+
+	return t
+}
+
+func TestReferenceTable_RoundTrip(t *testing.T) {
+	for protocol := uint8(MinProtocol); protocol <= MaxProtocol; protocol++ {
+		for flags := Flags(0); flags < 16; flags++ {
+			table := testTable(protocol, flags)
+
+			buffer := jagbuf.NewBuffer()
+			if err := table.Encode(buffer); err != nil {
+				t.Fatalf("Encode fail: protocol %d flags %#x: %v", protocol, flags, err)
+			}
+
+			decoded, err := DecodeReferenceTable(buffer)
+			if err != nil {
+				t.Fatalf("Decode fail: protocol %d flags %#x: %v", protocol, flags, err)
+			}
+
+			if !reflect.DeepEqual(decoded, table) {
+				t.Errorf("Decode fail: protocol %d flags %#x: expected %+v, got %+v", protocol, flags, table, decoded)
+			}
+			if buffer.ReadableBytes() != 0 {
+				t.Errorf("Decode fail: expected the whole table to be read, %d bytes left", buffer.ReadableBytes())
+			}
+		}
+	}
+}
+
+func TestReferenceTable_Layout(t *testing.T) {
+	table := &ReferenceTable{
+		Protocol: 7,
+		Version:  2,
+		Flags:    FlagNamed,
+		Groups: []Group{
+			{ID: 1, NameHash: -1, Checksum: 0xAABBCCDD, Version: 9, Files: []File{{ID: 0x8000, NameHash: 5}}},
+		},
+	}
+
+	expected := []byte{
+		7,          // protocol
+		0, 0, 0, 2, // version
+		1,    // flags
+		0, 1, // group count
+		0, 1, // group id
+		0xFF, 0xFF, 0xFF, 0xFF, // group name hash
+		0xAA, 0xBB, 0xCC, 0xDD, // checksum
+		0, 0, 0, 9, // version
+		0, 1, // file count
+		0x80, 0, 0x80, 0, // file id as a large smart
+		0, 0, 0, 5, // file name hash
+	}
+
+	buffer := jagbuf.NewBuffer()
+	if err := table.Encode(buffer); err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(buffer.Bytes(), expected) {
+		t.Errorf("Encode fail: expected %x, got %x", expected, buffer.Bytes())
+	}
+}
+
+func TestReferenceTable_Lookup(t *testing.T) {
+	table := testTable(7, FlagNamed)
+	table.Groups[1].NameHash = jagbuf.JS5NameHash("huffman")
+	table.Groups[1].Files[2].NameHash = jagbuf.JS5NameHash("m50_50")
+
+	if g := table.Group(3); g == nil || g.ID != 3 {
+		t.Fatalf("Group fail: expected group 3, got %v", g)
+	}
+	if g := table.Group(4); g != nil {
+		t.Errorf("Group fail: expected nil, got %v", g)
+	}
+
+	g := table.GroupByName("Huffman")
+	if g == nil || g.ID != 3 {
+		t.Fatalf("GroupByName fail: expected group 3, got %v", g)
+	}
+	if g := table.GroupByName("missing"); g != nil {
+		t.Errorf("GroupByName fail: expected nil, got %v", g)
+	}
+
+	if f := g.File(5); f == nil || f.ID != 5 {
+		t.Errorf("File fail: expected file 5, got %v", f)
+	}
+	if f := g.File(2); f != nil {
+		t.Errorf("File fail: expected nil, got %v", f)
+	}
+	if f := g.FileByName("M50_50"); f == nil || f.ID != 6 {
+		t.Errorf("FileByName fail: expected file 6, got %v", f)
+	}
+}
+
+func TestReferenceTable_Errors(t *testing.T) {
+	buffer := jagbuf.NewBuffer()
+	_ = testTable(7, FlagNamed|FlagDigests).Encode(buffer)
+	data := buffer.Bytes()
+
+	if _, err := DecodeReferenceTable(jagbuf.Wrap(data[:len(data)-1])); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode fail: expected io.EOF, got %v", err)
+	}
+
+	if _, err := DecodeReferenceTable(jagbuf.Wrap([]byte{8, 0, 0, 0, 0, 0, 0, 0})); !errors.Is(err, errors.ErrUnsupported) {
+		t.Errorf("Decode fail: expected ErrUnsupported, got %v", err)
+	}
+
+	// A huge group count is rejected before it is allocated.
+	huge := []byte{7, 0, 0, 0, 0, 0, 0xFF, 0xFF, 0xFF, 0xFF}
+	if _, err := DecodeReferenceTable(jagbuf.Wrap(huge)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Decode fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	// So is a group count that only fits what is left at a byte a group.
+	groups := jagbuf.NewBuffer()
+	groups.WriteUint8(6)
+	groups.WriteInt32(0)
+	groups.WriteUint8(uint8(FlagDigests))
+	groups.WriteUint16(1000)
+	groups.Write(make([]byte, 10000))
+	if _, err := DecodeReferenceTable(groups); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Decode fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	// So are huge file counts, even where each fits in what is left.
+	files := jagbuf.NewBuffer()
+	files.WriteUint8(6)
+	files.WriteInt32(0)
+	files.WriteUint8(0)
+	files.WriteUint16(300)
+	for i := range 300 {
+		files.WriteUint16(uint16(min(i, 1)))
+	}
+	files.Write(make([]byte, 300*8))
+	for range 300 {
+		files.WriteUint16(0xFFFF)
+	}
+	files.Write(make([]byte, 70000))
+	if _, err := DecodeReferenceTable(files); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Decode fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	invalid := map[string]*ReferenceTable{
+		"protocol":        {Protocol: 4},
+		"group order":     {Protocol: 7, Groups: []Group{{ID: 2}, {ID: 1}}},
+		"duplicate":       {Protocol: 7, Groups: []Group{{ID: 2}, {ID: 2}}},
+		"negative":        {Protocol: 7, Groups: []Group{{ID: -1}}},
+		"file order":      {Protocol: 7, Groups: []Group{{ID: 0, Files: []File{{ID: 1}, {ID: 0}}}}},
+		"delta for u16":   {Protocol: 6, Groups: []Group{{ID: 70000}}},
+		"file id for u16": {Protocol: 5, Groups: []Group{{ID: 0, Files: []File{{ID: 0x10000}}}}},
+	}
+
+	for name, table := range invalid {
+		buffer := jagbuf.NewBuffer()
+		if err := table.Encode(buffer); err == nil {
+			t.Errorf("Encode fail: expected an error for %s", name)
+		}
+		if buffer.ReadableBytes() != 0 {
+			t.Errorf("Encode fail: expected nothing written for %s", name)
+		}
+	}
+}
+
+func TestGroup_Layout(t *testing.T) {
+	files := [][]byte{[]byte("abcd"), []byte("xy"), {}}
+	expected := []byte{
+		'a', 'b', 'x', // stripe 0
+		'c', 'd', 'y', // stripe 1
+		0, 0, 0, 2, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // stripe 0 chunks 2, 1, 0
+		0, 0, 0, 2, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, // stripe 1 chunks 2, 1, 0
+		2, // stripes
+	}
+
+	group := JoinGroup(files, 2)
+	if !bytes.Equal(group, expected) {
+		t.Fatalf("JoinGroup fail: expected %x, got %x", expected, group)
+	}
+
+	split, err := SplitGroup(jagbuf.Wrap(group), len(files))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(split, files) {
+		t.Errorf("SplitGroup fail: expected %q, got %q", files, split)
+	}
+}
+
+func TestGroup_RoundTrip(t *testing.T) {
+	files := make([][]byte, 50)
+	for i := range files {
+		files[i] = bytes.Repeat([]byte{byte(i)}, i*7)
+	}
+
+	for _, stripes := range []int{1, 2, 7, MaxStripes} {
+		buffer := jagbuf.Wrap(JoinGroup(files, stripes))
+
+		split, err := SplitGroup(buffer, len(files))
+		if err != nil {
+			t.Fatalf("SplitGroup fail: %d stripes: %v", stripes, err)
+		}
+		if !reflect.DeepEqual(split, files) {
+			t.Errorf("SplitGroup fail: %d stripes: files did not round trip", stripes)
+		}
+		if buffer.ReadableBytes() != 0 {
+			t.Errorf("SplitGroup fail: expected the whole group to be read, %d bytes left", buffer.ReadableBytes())
+		}
+	}
+}
+
+func TestGroup_SingleFile(t *testing.T) {
+	data := []byte("single file\x01")
+
+	if group := JoinGroup([][]byte{data}, 3); !bytes.Equal(group, data) {
+		t.Errorf("JoinGroup fail: expected %q, got %q", data, group)
+	}
+
+	split, err := SplitGroup(jagbuf.Wrap(data), 1)
+	if err != nil || len(split) != 1 || !bytes.Equal(split[0], data) {
+		t.Errorf("SplitGroup fail: expected %q, got %q, %v", data, split, err)
+	}
+}
+
+func TestGroup_Errors(t *testing.T) {
+	valid := JoinGroup([][]byte{[]byte("abcd"), []byte("xy")}, 2)
+
+	tooLong := bytes.Clone(valid)
+	tooLong[9] = 9
+
+	extra := append([]byte{'!'}, valid...)
+
+	tests := map[string][]byte{
+		"empty":      {},
+		"no trailer": {'a', 'b', 5},
+		"too long":   tooLong,
+		"extra":      extra,
+		"negative":   {0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 1, 1},
+	}
+
+	for name, data := range tests {
+		if _, err := SplitGroup(jagbuf.Wrap(data), 2); err == nil {
+			t.Errorf("SplitGroup fail: expected an error for %s", name)
+		}
+	}
+
+	if _, err := SplitGroup(jagbuf.Wrap(valid), 0); err == nil {
+		t.Errorf("SplitGroup fail: expected an error for 0 files")
+	}
+
+	for _, test := range []struct {
+		name    string
+		files   [][]byte
+		stripes int
+	}{
+		{"0 stripes", [][]byte{{1}, {2}}, 0},
+		{"0 files", nil, 1},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("JoinGroup fail: expected a panic for %s", test.name)
+				}
+			}()
+			JoinGroup(test.files, test.stripes)
+		}()
+	}
+}
@@ -0,0 +1,430 @@
+// Package js5 implements the formats of the JS5 cache: the reference tables
+// describing the groups of each archive, and the groups themselves.
+package js5
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+// Flags are the optional fields present in a reference table.
+type Flags uint8
+
+const (
+	// FlagNamed marks a table with name hashes for every group and file.
+	FlagNamed Flags = 1 << iota
+	// FlagDigests marks a table with a whirlpool digest for every group.
+	FlagDigests
+	// FlagLengths marks a table with the lengths of every group.
+	FlagLengths
+	// FlagUncompressedChecksums marks a table with the checksum of every
+	// group after it is decompressed.
+	FlagUncompressedChecksums
+)
+
+const (
+	// MinProtocol and MaxProtocol bound the supported versions of the
+	// reference table format.
+	MinProtocol = 5
+	MaxProtocol = 7
+
+	// smartProtocol is the first protocol with ids and counts written as
+	// large smarts rather than u16.
+	smartProtocol = 7
+
+	// DigestLen is the length of a whirlpool digest.
+	DigestLen = 64
+)
+
+// ReferenceTable describes the groups of an archive, and the files within
+// each group.
+type ReferenceTable struct {
+	Protocol uint8
+	// Version is only written from protocol 6 onwards.
+	Version int32
+	Flags   Flags
+
+	// Groups are kept in order of id.
+	Groups []Group
+}
+
+// Group is an entry in a reference table.
+type Group struct {
+	ID       int
+	NameHash int32
+
+	// Checksum is the CRC-32 of the group's container, without its version.
+	Checksum             uint32
+	UncompressedChecksum uint32
+	Digest               [DigestLen]byte
+
+	// Length and UncompressedLength are the lengths of the group's
+	// container and of its data.
+	Length             uint32
+	UncompressedLength uint32
+
+	Version int32
+
+	// Files are kept in order of id.
+	Files []File
+}
+
+// File is an entry in a group.
+type File struct {
+	ID       int
+	NameHash int32
+}
+
+// NameHash returns the hash of name as the client computes it when looking
+// up a group or file, with the name lower cased first.
+func NameHash(name string) int32 {
+	return jagbuf.JS5NameHash(strings.ToLower(name))
+}
+
+// Group returns the group with the given id, or nil if there is none.
+func (t *ReferenceTable) Group(id int) *Group {
+	i, ok := slices.BinarySearchFunc(t.Groups, id, func(g Group, id int) int {
+		return cmp.Compare(g.ID, id)
+	})
+	if !ok {
+		return nil
+	}
+	return &t.Groups[i]
+}
+
+// GroupByName returns the group with the given name, or nil if there is
+// none.
+func (t *ReferenceTable) GroupByName(name string) *Group {
+	return t.GroupByNameHash(NameHash(name))
+}
+
+// GroupByNameHash returns the first group with the given name hash, or nil
+// if there is none.
+func (t *ReferenceTable) GroupByNameHash(hash int32) *Group {
+	for i := range t.Groups {
+		if t.Groups[i].NameHash == hash {
+			return &t.Groups[i]
+		}
+	}
+	return nil
+}
+
+// File returns the file with the given id, or nil if there is none.
+func (g *Group) File(id int) *File {
+	i, ok := slices.BinarySearchFunc(g.Files, id, func(f File, id int) int {
+		return cmp.Compare(f.ID, id)
+	})
+	if !ok {
+		return nil
+	}
+	return &g.Files[i]
+}
+
+// FileByName returns the file with the given name, or nil if there is none.
+func (g *Group) FileByName(name string) *File {
+	return g.FileByNameHash(NameHash(name))
+}
+
+// FileByNameHash returns the first file with the given name hash, or nil if
+// there is none.
+func (g *Group) FileByNameHash(hash int32) *File {
+	for i := range g.Files {
+		if g.Files[i].NameHash == hash {
+			return &g.Files[i]
+		}
+	}
+	return nil
+}
+
+func checkProtocol(protocol uint8) error {
+	if protocol < MinProtocol || protocol > MaxProtocol {
+		return fmt.Errorf("js5: reference table protocol %d: %w", protocol, errors.ErrUnsupported)
+	}
+	return nil
+}
+
+// DecodeReferenceTable reads a reference table from b.
+func DecodeReferenceTable(b *jagbuf.Buffer) (*ReferenceTable, error) {
+	d := jagbuf.NewDecoder(b)
+
+	t := &ReferenceTable{Protocol: d.ReadUint8()}
+	if d.Err() == nil {
+		if err := checkProtocol(t.Protocol); err != nil {
+			return nil, err
+		}
+	}
+
+	if t.Protocol >= 6 {
+		t.Version = d.ReadInt32()
+	}
+	t.Flags = Flags(d.ReadUint8())
+
+	count := readSmart(d, t.Protocol)
+	if err := checkCount(d, b, count, t.Flags.minGroupLen()); err != nil {
+		return nil, err
+	}
+
+	if count > 0 {
+		t.Groups = make([]Group, count)
+	}
+
+	id := 0
+	for i := range t.Groups {
+		id += readSmart(d, t.Protocol)
+		t.Groups[i].ID = id
+	}
+
+	if t.Flags&FlagNamed != 0 {
+		for i := range t.Groups {
+			t.Groups[i].NameHash = d.ReadInt32()
+		}
+	}
+
+	for i := range t.Groups {
+		t.Groups[i].Checksum = d.ReadUint32()
+	}
+
+	if t.Flags&FlagUncompressedChecksums != 0 {
+		for i := range t.Groups {
+			t.Groups[i].UncompressedChecksum = d.ReadUint32()
+		}
+	}
+
+	if t.Flags&FlagDigests != 0 {
+		for i := range t.Groups {
+			d.ReadBytes(t.Groups[i].Digest[:])
+		}
+	}
+
+	if t.Flags&FlagLengths != 0 {
+		for i := range t.Groups {
+			t.Groups[i].Length = d.ReadUint32()
+			t.Groups[i].UncompressedLength = d.ReadUint32()
+		}
+	}
+
+	for i := range t.Groups {
+		t.Groups[i].Version = d.ReadInt32()
+	}
+
+	// Every file id takes at least 2 bytes, so the total across all groups
+	// is checked before any files are allocated.
+	counts := make([]int, len(t.Groups))
+	total := 0
+	for i := range counts {
+		counts[i] = readSmart(d, t.Protocol)
+		total += counts[i]
+		if err := checkCount(d, b, total, 2); err != nil {
+			return nil, err
+		}
+	}
+
+	for i, count := range counts {
+		if count > 0 {
+			t.Groups[i].Files = make([]File, count)
+		}
+	}
+
+	for i := range t.Groups {
+		files := t.Groups[i].Files
+
+		id := 0
+		for j := range files {
+			id += readSmart(d, t.Protocol)
+			files[j].ID = id
+		}
+	}
+
+	if t.Flags&FlagNamed != 0 {
+		for i := range t.Groups {
+			files := t.Groups[i].Files
+			for j := range files {
+				files[j].NameHash = d.ReadInt32()
+			}
+		}
+	}
+
+	if err := d.Err(); err != nil {
+		return nil, fmt.Errorf("js5: reference table: %w", err)
+	}
+
+	return t, nil
+}
+
+// minGroupLen returns the fewest bytes a group takes in a table with flags f:
+// its id delta, checksum, version and file count, and any optional fields.
+func (f Flags) minGroupLen() int {
+	n := 2 + 4 + 4 + 2
+	if f&FlagNamed != 0 {
+		n += 4
+	}
+	if f&FlagUncompressedChecksums != 0 {
+		n += 4
+	}
+	if f&FlagDigests != 0 {
+		n += DigestLen
+	}
+	if f&FlagLengths != 0 {
+		n += 8
+	}
+	return n
+}
+
+// checkCount fails if there are not enough bytes left for count entries of
+// at least minLen bytes each, before they are allocated.
+func checkCount(d *jagbuf.Decoder, b *jagbuf.Buffer, count, minLen int) error {
+	if d.Err() == nil && int64(count)*int64(minLen) > int64(b.ReadableBytes()) {
+		return fmt.Errorf("js5: reference table with %d entries: %w", count, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+// Encode writes the reference table to b. Nothing is written if the table
+// cannot be encoded with its protocol, or its groups or files are not in
+// order of id.
+func (t *ReferenceTable) Encode(b *jagbuf.Buffer) error {
+	if err := t.check(); err != nil {
+		return err
+	}
+
+	b.WriteUint8(t.Protocol)
+	if t.Protocol >= 6 {
+		b.WriteInt32(t.Version)
+	}
+	b.WriteUint8(uint8(t.Flags))
+
+	writeSmart(b, t.Protocol, len(t.Groups))
+	prev := 0
+	for _, g := range t.Groups {
+		writeSmart(b, t.Protocol, g.ID-prev)
+		prev = g.ID
+	}
+
+	if t.Flags&FlagNamed != 0 {
+		for _, g := range t.Groups {
+			b.WriteInt32(g.NameHash)
+		}
+	}
+
+	for _, g := range t.Groups {
+		b.WriteUint32(g.Checksum)
+	}
+
+	if t.Flags&FlagUncompressedChecksums != 0 {
+		for _, g := range t.Groups {
+			b.WriteUint32(g.UncompressedChecksum)
+		}
+	}
+
+	if t.Flags&FlagDigests != 0 {
+		for _, g := range t.Groups {
+			b.Write(g.Digest[:])
+		}
+	}
+
+	if t.Flags&FlagLengths != 0 {
+		for _, g := range t.Groups {
+			b.WriteUint32(g.Length)
+			b.WriteUint32(g.UncompressedLength)
+		}
+	}
+
+	for _, g := range t.Groups {
+		b.WriteInt32(g.Version)
+	}
+
+	for _, g := range t.Groups {
+		writeSmart(b, t.Protocol, len(g.Files))
+	}
+
+	for _, g := range t.Groups {
+		prev := 0
+		for _, f := range g.Files {
+			writeSmart(b, t.Protocol, f.ID-prev)
+			prev = f.ID
+		}
+	}
+
+	if t.Flags&FlagNamed != 0 {
+		for _, g := range t.Groups {
+			for _, f := range g.Files {
+				b.WriteInt32(f.NameHash)
+			}
+		}
+	}
+
+	return nil
+}
+
+// check reports whether the table can be encoded.
+func (t *ReferenceTable) check() error {
+	if err := checkProtocol(t.Protocol); err != nil {
+		return err
+	}
+
+	if err := checkIDs(t.Protocol, len(t.Groups), func(i int) int { return t.Groups[i].ID }); err != nil {
+		return fmt.Errorf("js5: groups: %w", err)
+	}
+
+	for _, g := range t.Groups {
+		if err := checkIDs(t.Protocol, len(g.Files), func(i int) int { return g.Files[i].ID }); err != nil {
+			return fmt.Errorf("js5: group %d files: %w", g.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// checkIDs checks that n ids are ascending and that they and their count
+// fit the protocol.
+func checkIDs(protocol uint8, n int, id func(i int) int) error {
+	limit := maxSmart(protocol)
+	if n > limit {
+		return fmt.Errorf("%d entries out of range", n)
+	}
+
+	prev := 0
+	for i := range n {
+		delta := id(i) - prev
+		if (i > 0 && delta <= 0) || delta < 0 || delta > limit {
+			return fmt.Errorf("id %d out of order or range", id(i))
+		}
+		prev = id(i)
+	}
+
+	return nil
+}
+
+// maxSmart returns the largest id delta or count that can be written with
+// the protocol.
+func maxSmart(protocol uint8) int {
+	if protocol >= smartProtocol {
+		return 0x7FFFFFFF
+	}
+	return 0xFFFF
+}
+
+// readSmart reads an id delta or count, written as a u16 before protocol 7
+// and as a large smart from then on: a u16 if the high bit is clear, or
+// otherwise a u32 with the high bit set.
+func readSmart(d *jagbuf.Decoder, protocol uint8) int {
+	v := d.ReadUint16()
+	if protocol < smartProtocol || v < 0x8000 {
+		return int(v)
+	}
+	return int(v&0x7FFF)<<16 | int(d.ReadUint16())
+}
+
+func writeSmart(b *jagbuf.Buffer, protocol uint8, v int) {
+	if protocol < smartProtocol || v < 0x8000 {
+		b.WriteUint16(uint16(v))
+	} else {
+		b.WriteUint32(uint32(v) | 0x80000000)
+	}
+}
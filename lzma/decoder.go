@@ -0,0 +1,175 @@
+package lzma
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+// maxPrealloc caps the output allocated up front, so a bogus size cannot
+// cause a large allocation before any data is decoded.
+const maxPrealloc = 1 << 20
+
+// endMarker is the distance of the optional end of stream marker.
+const endMarker = 0xFFFFFFFF
+
+type decoder struct {
+	*model
+	rc *rangeDecoder
+}
+
+func newDecoder(b *jagbuf.Buffer, p Properties) *decoder {
+	return &decoder{model: newModel(p), rc: newRangeDecoder(b)}
+}
+
+func (d *decoder) decode(size int) ([]byte, error) {
+	if !d.rc.valid() {
+		return nil, d.err("invalid stream start")
+	}
+
+	out := make([]byte, 0, min(size, maxPrealloc))
+	for len(out) < size {
+		pos := len(out)
+		posState := uint32(pos & (1<<d.PB - 1))
+		s := uint32(d.state)
+
+		if d.rc.decodeBit(&d.isMatch[s<<posBitsMax+posState]) == 0 {
+			out = append(out, d.decodeLiteral(out))
+			d.state.literal()
+		} else {
+			var length uint32
+			if d.rc.decodeBit(&d.isRep[s]) != 0 {
+				if pos == 0 {
+					return nil, d.err("repeated match at start")
+				}
+
+				if d.rc.decodeBit(&d.isRepG0[s]) == 0 {
+					if d.rc.decodeBit(&d.isRep0Long[s<<posBitsMax+posState]) == 0 {
+						d.state.shortRep()
+						out = append(out, out[pos-int(d.reps[0])-1])
+						continue
+					}
+				} else {
+					var dist uint32
+					if d.rc.decodeBit(&d.isRepG1[s]) == 0 {
+						dist = d.reps[1]
+					} else {
+						if d.rc.decodeBit(&d.isRepG2[s]) == 0 {
+							dist = d.reps[2]
+						} else {
+							dist = d.reps[3]
+							d.reps[3] = d.reps[2]
+						}
+						d.reps[2] = d.reps[1]
+					}
+					d.reps[1] = d.reps[0]
+					d.reps[0] = dist
+				}
+
+				length = d.decodeLength(&d.repLen, posState)
+				d.state.rep()
+			} else {
+				d.reps[3], d.reps[2], d.reps[1] = d.reps[2], d.reps[1], d.reps[0]
+
+				length = d.decodeLength(&d.matchLen, posState)
+				d.state.match()
+
+				d.reps[0] = d.decodeDistance(length)
+				if d.reps[0] == endMarker {
+					break
+				}
+			}
+
+			if d.reps[0] >= uint32(pos) || d.reps[0] >= d.DictSize {
+				return nil, d.err("distance %d out of range", d.reps[0]+1)
+			}
+			if int(length) > size-pos {
+				return nil, d.err("match past the end of the data")
+			}
+
+			from := pos - int(d.reps[0]) - 1
+			for i := range int(length) {
+				out = append(out, out[from+i])
+			}
+		}
+
+		if d.rc.eof {
+			return nil, fmt.Errorf("lzma: %w", io.ErrUnexpectedEOF)
+		}
+	}
+
+	if d.rc.eof {
+		return nil, fmt.Errorf("lzma: %w", io.ErrUnexpectedEOF)
+	}
+	if len(out) != size {
+		return nil, d.err("end of stream after %d of %d bytes", len(out), size)
+	}
+
+	return out, nil
+}
+
+func (d *decoder) err(format string, args ...any) error {
+	return fmt.Errorf("%w: "+format, append([]any{ErrCorrupt}, args...)...)
+}
+
+func (d *decoder) decodeLiteral(out []byte) byte {
+	pos := len(out)
+
+	var prev byte
+	if pos > 0 {
+		prev = out[pos-1]
+	}
+	probs := d.literalProbs(pos, prev)
+
+	// After a match the literal is coded against the byte the match would
+	// have continued with, until the first bit that differs.
+	symbol := uint32(1)
+	if !d.state.isLiteral() && pos > int(d.reps[0]) {
+		matchByte := uint32(out[pos-int(d.reps[0])-1])
+		for symbol < 0x100 {
+			matchBit := matchByte >> 7 & 1
+			matchByte <<= 1
+
+			bit := d.rc.decodeBit(&probs[(1+matchBit)<<8+symbol])
+			symbol = symbol<<1 | bit
+			if bit != matchBit {
+				break
+			}
+		}
+	}
+
+	for symbol < 0x100 {
+		symbol = symbol<<1 | d.rc.decodeBit(&probs[symbol])
+	}
+
+	return byte(symbol)
+}
+
+// decodeLength decodes the length of a match.
+func (d *decoder) decodeLength(l *lengthModel, posState uint32) uint32 {
+	if d.rc.decodeBit(&l.choice) == 0 {
+		return minMatchLen + d.rc.decodeTree(l.low[posState][:], lenLowBits)
+	}
+	if d.rc.decodeBit(&l.choice2) == 0 {
+		return minMatchLen + lenLow + d.rc.decodeTree(l.mid[posState][:], lenMidBits)
+	}
+	return minMatchLen + lenLow + lenMid + d.rc.decodeTree(l.high[:], lenHighBits)
+}
+
+// decodeDistance decodes the distance of a match of length, less 1.
+func (d *decoder) decodeDistance(length uint32) uint32 {
+	slot := d.rc.decodeTree(d.posSlot[lenState(length)][:], posSlotBits)
+	if slot < startPosSlot {
+		return slot
+	}
+
+	footerBits := slot>>1 - 1
+	dist := (2 | slot&1) << footerBits
+	if slot < endPosSlot {
+		return dist + d.rc.decodeReverseTree(d.posSpecial[dist-slot:], footerBits)
+	}
+
+	dist += d.rc.decodeDirectBits(footerBits-alignBits) << alignBits
+	return dist + d.rc.decodeReverseTree(d.align[:], alignBits)
+}
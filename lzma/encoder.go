@@ -0,0 +1,218 @@
+package lzma
+
+import "github.com/apogee-rs/jagbuf"
+
+const (
+	hashBits = 16
+
+	// maxChain is the most earlier positions searched for a match.
+	maxChain = 64
+
+	// niceMatchLen is the length at which a match is taken without
+	// searching for a longer one.
+	niceMatchLen = 128
+)
+
+// encoder compresses greedily, taking the longest match found at each
+// position, or the last distance used if that is nearly as long.
+type encoder struct {
+	*model
+	rc *rangeEncoder
+
+	head []int32
+	prev []int32
+}
+
+func newEncoder(b *jagbuf.Buffer, p Properties) *encoder {
+	return &encoder{model: newModel(p), rc: newRangeEncoder(b)}
+}
+
+func (e *encoder) encode(data []byte) {
+	e.head = make([]int32, 1<<hashBits)
+	for i := range e.head {
+		e.head[i] = -1
+	}
+	e.prev = make([]int32, len(data))
+
+	for pos := 0; pos < len(data); {
+		posState := uint32(pos & (1<<e.PB - 1))
+
+		repLen := 0
+		if pos > int(e.reps[0]) {
+			repLen = matchLen(data, pos, pos-int(e.reps[0])-1)
+		}
+		length, dist := e.findMatch(data, pos)
+
+		n := 1
+		switch {
+		case repLen >= minMatchLen && repLen+1 >= length:
+			e.encodeRep0(uint32(repLen), posState)
+			n = repLen
+		case length >= 3:
+			e.encodeMatch(uint32(length), dist, posState)
+			n = length
+		default:
+			e.encodeLiteral(data, pos, posState)
+		}
+
+		for range n {
+			e.insert(data, pos)
+			pos++
+		}
+	}
+
+	e.rc.flush()
+}
+
+func (e *encoder) encodeLiteral(data []byte, pos int, posState uint32) {
+	s := uint32(e.state)
+	e.rc.encodeBit(&e.isMatch[s<<posBitsMax+posState], 0)
+
+	var prev byte
+	if pos > 0 {
+		prev = data[pos-1]
+	}
+	probs := e.literalProbs(pos, prev)
+
+	v := uint32(data[pos])
+	symbol := uint32(1)
+	i := 7
+	if !e.state.isLiteral() && pos > int(e.reps[0]) {
+		matchByte := uint32(data[pos-int(e.reps[0])-1])
+		for ; i >= 0; i-- {
+			matchBit := matchByte >> i & 1
+			bit := v >> i & 1
+
+			e.rc.encodeBit(&probs[(1+matchBit)<<8+symbol], bit)
+			symbol = symbol<<1 | bit
+			if bit != matchBit {
+				i--
+				break
+			}
+		}
+	}
+
+	for ; i >= 0; i-- {
+		bit := v >> i & 1
+		e.rc.encodeBit(&probs[symbol], bit)
+		symbol = symbol<<1 | bit
+	}
+
+	e.state.literal()
+}
+
+// encodeMatch encodes a match of length at dist, less 1.
+func (e *encoder) encodeMatch(length, dist, posState uint32) {
+	s := uint32(e.state)
+	e.rc.encodeBit(&e.isMatch[s<<posBitsMax+posState], 1)
+	e.rc.encodeBit(&e.isRep[s], 0)
+
+	e.encodeLength(&e.matchLen, length, posState)
+	e.encodeDistance(dist, length)
+
+	e.reps[3], e.reps[2], e.reps[1], e.reps[0] = e.reps[2], e.reps[1], e.reps[0], dist
+	e.state.match()
+}
+
+// encodeRep0 encodes a match of length at the last distance used.
+func (e *encoder) encodeRep0(length, posState uint32) {
+	s := uint32(e.state)
+	e.rc.encodeBit(&e.isMatch[s<<posBitsMax+posState], 1)
+	e.rc.encodeBit(&e.isRep[s], 1)
+	e.rc.encodeBit(&e.isRepG0[s], 0)
+	e.rc.encodeBit(&e.isRep0Long[s<<posBitsMax+posState], 1)
+
+	e.encodeLength(&e.repLen, length, posState)
+	e.state.rep()
+}
+
+func (e *encoder) encodeLength(l *lengthModel, length, posState uint32) {
+	length -= minMatchLen
+
+	switch {
+	case length < lenLow:
+		e.rc.encodeBit(&l.choice, 0)
+		e.rc.encodeTree(l.low[posState][:], lenLowBits, length)
+	case length < lenLow+lenMid:
+		e.rc.encodeBit(&l.choice, 1)
+		e.rc.encodeBit(&l.choice2, 0)
+		e.rc.encodeTree(l.mid[posState][:], lenMidBits, length-lenLow)
+	default:
+		e.rc.encodeBit(&l.choice, 1)
+		e.rc.encodeBit(&l.choice2, 1)
+		e.rc.encodeTree(l.high[:], lenHighBits, length-lenLow-lenMid)
+	}
+}
+
+func (e *encoder) encodeDistance(dist, length uint32) {
+	slot := distSlot(dist)
+	e.rc.encodeTree(e.posSlot[lenState(length)][:], posSlotBits, slot)
+	if slot < startPosSlot {
+		return
+	}
+
+	footerBits := slot>>1 - 1
+	base := (2 | slot&1) << footerBits
+	reduced := dist - base
+	if slot < endPosSlot {
+		e.rc.encodeReverseTree(e.posSpecial[base-slot:], footerBits, reduced)
+		return
+	}
+
+	e.rc.encodeDirectBits(reduced>>alignBits, footerBits-alignBits)
+	e.rc.encodeReverseTree(e.align[:], alignBits, reduced&(1<<alignBits-1))
+}
+
+func hash3(data []byte, pos int) uint32 {
+	v := uint32(data[pos])<<16 | uint32(data[pos+1])<<8 | uint32(data[pos+2])
+	return v * 2654435761 >> (32 - hashBits)
+}
+
+// insert adds pos to the chain of positions with the same next 3 bytes.
+func (e *encoder) insert(data []byte, pos int) {
+	if pos+3 > len(data) {
+		return
+	}
+
+	h := hash3(data, pos)
+	e.prev[pos] = e.head[h]
+	e.head[h] = int32(pos)
+}
+
+// findMatch returns the longest match for pos within the dictionary, and
+// its distance less 1.
+func (e *encoder) findMatch(data []byte, pos int) (int, uint32) {
+	if pos+3 > len(data) {
+		return 0, 0
+	}
+
+	best, dist := 0, 0
+	cand := e.head[hash3(data, pos)]
+	for range maxChain {
+		if cand < 0 || pos-int(cand) > int(e.DictSize) {
+			break
+		}
+
+		if n := matchLen(data, pos, int(cand)); n > best {
+			best, dist = n, pos-int(cand)-1
+			if n >= niceMatchLen {
+				break
+			}
+		}
+		cand = e.prev[cand]
+	}
+
+	return best, uint32(dist)
+}
+
+// matchLen returns how many bytes at pos repeat those at from, up to
+// maxMatchLen.
+func matchLen(data []byte, pos, from int) int {
+	limit := min(len(data)-pos, maxMatchLen)
+
+	n := 0
+	for n < limit && data[pos+n] == data[from+n] {
+		n++
+	}
+	return n
+}
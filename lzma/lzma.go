@@ -0,0 +1,261 @@
+// Package lzma implements the LZMA compression used by JS5 containers.
+//
+// The client stores LZMA data as the 5 byte properties header followed by
+// the raw stream, without the uncompressed size of the .lzma format, as the
+// size is already given by the container. The end of stream marker is not
+// written and not required when decoding.
+package lzma
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+// HeaderLen is the length of the properties header in front of the stream.
+const HeaderLen = 5
+
+// ErrCorrupt is returned when the stream cannot be decoded.
+var ErrCorrupt = errors.New("lzma: corrupt data")
+
+const (
+	states = 12
+
+	posBitsMax = 4
+	posStates  = 1 << posBitsMax
+
+	lenStates    = 4
+	posSlotBits  = 6
+	alignBits    = 4
+	startPosSlot = 4
+	endPosSlot   = 14
+	fullDist     = 1 << (endPosSlot >> 1)
+
+	lenLowBits  = 3
+	lenMidBits  = 3
+	lenHighBits = 8
+	lenLow      = 1 << lenLowBits
+	lenMid      = 1 << lenMidBits
+
+	minMatchLen = 2
+	maxMatchLen = minMatchLen + lenLow + lenMid + 1<<lenHighBits - 1
+
+	literalCoderSize = 0x300
+)
+
+// Properties are the parameters of an LZMA stream, encoded in its header.
+type Properties struct {
+	// LC is the number of high bits of the previous byte used as context
+	// for literals, LP the number of low bits of the position used and PB
+	// the number of low bits of the position used for everything else.
+	LC, LP, PB uint
+
+	// DictSize is the size of the dictionary the decoder must keep.
+	DictSize uint32
+}
+
+// DefaultProperties are the properties of streams written by Compress,
+// which sizes the dictionary to fit the data.
+var DefaultProperties = Properties{LC: 3, LP: 0, PB: 2}
+
+const (
+	minDictSize = 1 << 12
+	maxDictSize = 1 << 26
+)
+
+// ReadHeader reads the properties header of a stream. Nothing is consumed
+// unless the header is valid.
+func ReadHeader(b *jagbuf.Buffer) (Properties, error) {
+	if b.ReadableBytes() < HeaderLen {
+		return Properties{}, io.EOF
+	}
+
+	d, _ := b.PeekUint8()
+	if d >= 9*5*5 {
+		return Properties{}, fmt.Errorf("%w: properties byte %#x", ErrCorrupt, d)
+	}
+
+	b.Skip(1)
+	dictSize, _ := b.ReadUint32LE()
+
+	return Properties{
+		LC:       uint(d % 9),
+		LP:       uint(d / 9 % 5),
+		PB:       uint(d / 45),
+		DictSize: dictSize,
+	}, nil
+}
+
+// WriteHeader writes the properties header of a stream.
+func WriteHeader(b *jagbuf.Buffer, p Properties) {
+	b.WriteUint8(uint8((p.PB*5+p.LP)*9 + p.LC))
+	b.WriteUint32LE(p.DictSize)
+}
+
+// Decompress reads a stream with its properties header from b and returns
+// the first size bytes it decodes to. Only the bytes of the stream needed
+// to decode them are consumed.
+func Decompress(b *jagbuf.Buffer, size int) ([]byte, error) {
+	p, err := ReadHeader(b)
+	if err != nil {
+		return nil, err
+	}
+
+	d := newDecoder(b, p)
+	return d.decode(size)
+}
+
+// Compress writes data to b compressed as a stream with its properties
+// header, using DefaultProperties with a dictionary large enough to cover
+// all of data.
+func Compress(b *jagbuf.Buffer, data []byte) {
+	p := DefaultProperties
+	p.DictSize = dictSizeFor(len(data))
+
+	WriteHeader(b, p)
+	newEncoder(b, p).encode(data)
+}
+
+// dictSizeFor returns the smallest power of 2 dictionary covering n bytes,
+// within the sizes the client can be expected to allocate.
+func dictSizeFor(n int) uint32 {
+	if n <= minDictSize {
+		return minDictSize
+	}
+	if n >= maxDictSize {
+		return maxDictSize
+	}
+	return 1 << bits.Len(uint(n-1))
+}
+
+// state is the kind of the last few packets, used as context when coding
+// the next one.
+type state uint8
+
+func (s state) isLiteral() bool {
+	return s < 7
+}
+
+func (s *state) literal() {
+	switch {
+	case *s < 4:
+		*s = 0
+	case *s < 10:
+		*s -= 3
+	default:
+		*s -= 6
+	}
+}
+
+func (s *state) match() {
+	if s.isLiteral() {
+		*s = 7
+	} else {
+		*s = 10
+	}
+}
+
+func (s *state) rep() {
+	if s.isLiteral() {
+		*s = 8
+	} else {
+		*s = 11
+	}
+}
+
+func (s *state) shortRep() {
+	if s.isLiteral() {
+		*s = 9
+	} else {
+		*s = 11
+	}
+}
+
+// lengthModel holds the probabilities of match lengths.
+type lengthModel struct {
+	choice  prob
+	choice2 prob
+	low     [posStates][lenLow]prob
+	mid     [posStates][lenMid]prob
+	high    [1 << lenHighBits]prob
+}
+
+// model holds every probability of a stream, shared by the encoder and
+// decoder so they adapt in step.
+type model struct {
+	Properties
+
+	literal    []prob
+	isMatch    [states << posBitsMax]prob
+	isRep      [states]prob
+	isRepG0    [states]prob
+	isRepG1    [states]prob
+	isRepG2    [states]prob
+	isRep0Long [states << posBitsMax]prob
+	posSlot    [lenStates][1 << posSlotBits]prob
+	posSpecial [1 + fullDist - endPosSlot]prob
+	align      [1 << alignBits]prob
+	matchLen   lengthModel
+	repLen     lengthModel
+
+	state state
+	reps  [4]uint32
+}
+
+func newModel(p Properties) *model {
+	m := &model{
+		Properties: p,
+		literal:    make([]prob, literalCoderSize<<(p.LC+p.LP)),
+	}
+
+	initProbs(m.literal)
+	initProbs(m.isMatch[:])
+	initProbs(m.isRep[:])
+	initProbs(m.isRepG0[:])
+	initProbs(m.isRepG1[:])
+	initProbs(m.isRepG2[:])
+	initProbs(m.isRep0Long[:])
+	for i := range m.posSlot {
+		initProbs(m.posSlot[i][:])
+	}
+	initProbs(m.posSpecial[:])
+	initProbs(m.align[:])
+	m.matchLen.init()
+	m.repLen.init()
+
+	return m
+}
+
+func (l *lengthModel) init() {
+	l.choice, l.choice2 = probInit, probInit
+	for i := range l.low {
+		initProbs(l.low[i][:])
+		initProbs(l.mid[i][:])
+	}
+	initProbs(l.high[:])
+}
+
+// literalProbs returns the probabilities of a literal at pos following
+// prev.
+func (m *model) literalProbs(pos int, prev byte) []prob {
+	lit := (pos&(1<<m.LP-1))<<m.LC + int(prev)>>(8-m.LC)
+	return m.literal[literalCoderSize*lit : literalCoderSize*(lit+1)]
+}
+
+// distSlot returns the slot of a distance, which is 2 for each bit of the
+// distance plus its second highest bit.
+func distSlot(dist uint32) uint32 {
+	if dist < startPosSlot {
+		return dist
+	}
+	n := uint32(bits.Len32(dist))
+	return 2*(n-1) + (dist>>(n-2))&1
+}
+
+// lenState returns the context a distance is coded in, from its length.
+func lenState(length uint32) uint32 {
+	return min(length-minMatchLen, lenStates-1)
+}
@@ -0,0 +1,161 @@
+package lzma
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math/rand"
+	"os/exec"
+	"testing"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+func testData() map[string][]byte {
+	random := make([]byte, 200000)
+	rand.New(rand.NewSource(1)).Read(random)
+
+	mixed := make([]byte, 0, 300000)
+	r := rand.New(rand.NewSource(2))
+	for len(mixed) < 300000 {
+		if r.Intn(2) == 0 || len(mixed) < 1000 {
+			mixed = append(mixed, random[:r.Intn(100)]...)
+		} else {
+			from := r.Intn(len(mixed) - 300)
+			mixed = append(mixed, mixed[from:from+r.Intn(300)]...)
+		}
+	}
+
+	return map[string][]byte{
+		"empty":       {},
+		"single byte": {'a'},
+		"short":       []byte("hello hello hello jagex"),
+		"long run":    bytes.Repeat([]byte{0}, 100000),
+		"text":        bytes.Repeat([]byte("the quick brown fox jumps over the lazy dog. "), 2000),
+		"random":      random,
+		"mixed":       mixed,
+	}
+}
+
+func TestCompress_RoundTrip(t *testing.T) {
+	for name, data := range testData() {
+		buffer := jagbuf.NewBuffer()
+		Compress(buffer, data)
+		buffer.WriteUint8(0xAA)
+
+		decompressed, err := Decompress(buffer, len(data))
+		if err != nil {
+			t.Fatalf("Decompress fail: %s: %v", name, err)
+		}
+
+		if !bytes.Equal(decompressed, data) {
+			t.Errorf("Decompress fail: %s: expected %d bytes back, got %d", name, len(data), len(decompressed))
+		}
+
+		if v, _ := buffer.ReadUint8(); v != 0xAA || buffer.ReadableBytes() != 0 {
+			t.Errorf("Decompress fail: %s: expected the whole stream to be consumed", name)
+		}
+	}
+}
+
+func TestCompress_Ratio(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible "), 10000)
+
+	buffer := jagbuf.NewBuffer()
+	Compress(buffer, data)
+
+	if buffer.ReadableBytes() > len(data)/100 {
+		t.Errorf("Compress fail: expected at most %d bytes, got %d", len(data)/100, buffer.ReadableBytes())
+	}
+}
+
+func xz(t *testing.T, stdin []byte, args ...string) []byte {
+	t.Helper()
+
+	path, err := exec.LookPath("xz")
+	if err != nil {
+		t.Skip("xz not installed")
+	}
+
+	cmd := exec.Command(path, append([]string{"--format=lzma", "-c"}, args...)...)
+	cmd.Stdin = bytes.NewReader(stdin)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDecompress_Xz(t *testing.T) {
+	for name, data := range testData() {
+		// Strip the uncompressed size from the .lzma header, leaving what
+		// the client stores.
+		out := xz(t, data, "-z")
+		stream := append(out[:HeaderLen:HeaderLen], out[HeaderLen+8:]...)
+
+		decompressed, err := Decompress(jagbuf.Wrap(stream), len(data))
+		if err != nil {
+			t.Fatalf("Decompress fail: %s: %v", name, err)
+		}
+
+		if !bytes.Equal(decompressed, data) {
+			t.Errorf("Decompress fail: %s: expected %d bytes back, got %d", name, len(data), len(decompressed))
+		}
+	}
+}
+
+func TestCompress_Xz(t *testing.T) {
+	for name, data := range testData() {
+		buffer := jagbuf.NewBuffer()
+		Compress(buffer, data)
+
+		// Add the uncompressed size to make a .lzma file.
+		stream := buffer.Bytes()
+		file := append(stream[:HeaderLen:HeaderLen], binary.LittleEndian.AppendUint64(nil, uint64(len(data)))...)
+		file = append(file, stream[HeaderLen:]...)
+
+		if out := xz(t, file, "-d"); !bytes.Equal(out, data) {
+			t.Errorf("Compress fail: %s: expected xz to decompress %d bytes, got %d", name, len(data), len(out))
+		}
+	}
+}
+
+func TestDecompress_Errors(t *testing.T) {
+	buffer := jagbuf.NewBuffer()
+	Compress(buffer, []byte("hello hello hello jagex"))
+	stream := buffer.Bytes()
+
+	if _, err := Decompress(jagbuf.Wrap(stream[:len(stream)-3]), 23); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("Decompress fail: expected io.ErrUnexpectedEOF, got %v", err)
+	}
+
+	if _, err := Decompress(jagbuf.Wrap(stream[:3]), 23); !errors.Is(err, io.EOF) {
+		t.Errorf("Decompress fail: expected io.EOF, got %v", err)
+	}
+
+	bad := append([]byte{0xFF}, stream[1:]...)
+	if _, err := Decompress(jagbuf.Wrap(bad), 23); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Decompress fail: expected ErrCorrupt, got %v", err)
+	}
+
+	// A stream must start with a 0 byte.
+	bad = append(stream[:HeaderLen:HeaderLen], 1, 2, 3, 4, 5)
+	if _, err := Decompress(jagbuf.Wrap(bad), 23); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("Decompress fail: expected ErrCorrupt, got %v", err)
+	}
+}
+
+func TestHeader(t *testing.T) {
+	buffer := jagbuf.NewBuffer()
+	WriteHeader(buffer, Properties{LC: 3, LP: 0, PB: 2, DictSize: 1 << 20})
+
+	if !bytes.Equal(buffer.Bytes(), []byte{0x5D, 0, 0, 0x10, 0}) {
+		t.Fatalf("WriteHeader fail: got %x", buffer.Bytes())
+	}
+
+	p, err := ReadHeader(buffer)
+	if err != nil || p != (Properties{LC: 3, LP: 0, PB: 2, DictSize: 1 << 20}) {
+		t.Errorf("ReadHeader fail: got %+v, %v", p, err)
+	}
+}
@@ -0,0 +1,206 @@
+package lzma
+
+import "github.com/apogee-rs/jagbuf"
+
+const (
+	probBits = 11
+	probInit = 1 << (probBits - 1)
+	moveBits = 5
+	rangeTop = 1 << 24
+)
+
+// prob is the probability of a bit being 0, out of 1<<probBits.
+type prob uint16
+
+func initProbs(probs []prob) {
+	for i := range probs {
+		probs[i] = probInit
+	}
+}
+
+// rangeDecoder decodes bits from a stream read from a Buffer. Running out
+// of input is recorded in eof rather than checked for every bit, and the
+// decoder reads zeroes from then on.
+type rangeDecoder struct {
+	b     *jagbuf.Buffer
+	rng   uint32
+	code  uint32
+	eof   bool
+	first byte
+}
+
+func newRangeDecoder(b *jagbuf.Buffer) *rangeDecoder {
+	d := &rangeDecoder{b: b, rng: 0xFFFFFFFF}
+
+	d.first = d.next()
+	for range 4 {
+		d.code = d.code<<8 | uint32(d.next())
+	}
+
+	return d
+}
+
+// valid reports whether the stream starts as a stream written by an
+// encoder must.
+func (d *rangeDecoder) valid() bool {
+	return d.first == 0 && d.code != d.rng
+}
+
+func (d *rangeDecoder) next() byte {
+	v, err := d.b.ReadUint8()
+	if err != nil {
+		d.eof = true
+	}
+	return v
+}
+
+func (d *rangeDecoder) normalize() {
+	if d.rng < rangeTop {
+		d.rng <<= 8
+		d.code = d.code<<8 | uint32(d.next())
+	}
+}
+
+func (d *rangeDecoder) decodeBit(p *prob) uint32 {
+	bound := (d.rng >> probBits) * uint32(*p)
+
+	var bit uint32
+	if d.code < bound {
+		*p += (1<<probBits - *p) >> moveBits
+		d.rng = bound
+	} else {
+		*p -= *p >> moveBits
+		d.code -= bound
+		d.rng -= bound
+		bit = 1
+	}
+
+	d.normalize()
+	return bit
+}
+
+func (d *rangeDecoder) decodeDirectBits(n uint32) uint32 {
+	var v uint32
+	for range n {
+		d.rng >>= 1
+		bit := uint32(0)
+		if d.code >= d.rng {
+			d.code -= d.rng
+			bit = 1
+		}
+		v = v<<1 | bit
+		d.normalize()
+	}
+	return v
+}
+
+// decodeTree decodes n bits with a tree of probabilities, highest bit
+// first.
+func (d *rangeDecoder) decodeTree(probs []prob, n uint32) uint32 {
+	m := uint32(1)
+	for range n {
+		m = m<<1 | d.decodeBit(&probs[m])
+	}
+	return m - 1<<n
+}
+
+// decodeReverseTree decodes n bits with a tree of probabilities, lowest bit
+// first.
+func (d *rangeDecoder) decodeReverseTree(probs []prob, n uint32) uint32 {
+	m := uint32(1)
+	var v uint32
+	for i := range n {
+		bit := d.decodeBit(&probs[m])
+		m = m<<1 | bit
+		v |= bit << i
+	}
+	return v
+}
+
+// rangeEncoder encodes bits into a Buffer.
+type rangeEncoder struct {
+	b         *jagbuf.Buffer
+	low       uint64
+	rng       uint32
+	cache     byte
+	cacheSize int
+}
+
+func newRangeEncoder(b *jagbuf.Buffer) *rangeEncoder {
+	return &rangeEncoder{b: b, rng: 0xFFFFFFFF, cacheSize: 1}
+}
+
+// shiftLow writes out the top byte of low, holding back bytes of 0xFF
+// until it is known whether a carry will propagate into them.
+func (e *rangeEncoder) shiftLow() {
+	if uint32(e.low) < 0xFF000000 || e.low>>32 != 0 {
+		carry := byte(e.low >> 32)
+		v := e.cache
+		for ; e.cacheSize > 0; e.cacheSize-- {
+			e.b.WriteUint8(v + carry)
+			v = 0xFF
+		}
+		e.cache = byte(e.low >> 24)
+	}
+
+	e.cacheSize++
+	e.low = uint64(uint32(e.low) << 8)
+}
+
+func (e *rangeEncoder) normalize() {
+	for e.rng < rangeTop {
+		e.rng <<= 8
+		e.shiftLow()
+	}
+}
+
+func (e *rangeEncoder) encodeBit(p *prob, bit uint32) {
+	bound := (e.rng >> probBits) * uint32(*p)
+
+	if bit == 0 {
+		*p += (1<<probBits - *p) >> moveBits
+		e.rng = bound
+	} else {
+		*p -= *p >> moveBits
+		e.low += uint64(bound)
+		e.rng -= bound
+	}
+
+	e.normalize()
+}
+
+func (e *rangeEncoder) encodeDirectBits(v, n uint32) {
+	for i := int(n) - 1; i >= 0; i-- {
+		e.rng >>= 1
+		if v>>i&1 != 0 {
+			e.low += uint64(e.rng)
+		}
+		e.normalize()
+	}
+}
+
+func (e *rangeEncoder) encodeTree(probs []prob, n, v uint32) {
+	m := uint32(1)
+	for i := int(n) - 1; i >= 0; i-- {
+		bit := v >> i & 1
+		e.encodeBit(&probs[m], bit)
+		m = m<<1 | bit
+	}
+}
+
+func (e *rangeEncoder) encodeReverseTree(probs []prob, n, v uint32) {
+	m := uint32(1)
+	for range n {
+		bit := v & 1
+		e.encodeBit(&probs[m], bit)
+		m = m<<1 | bit
+		v >>= 1
+	}
+}
+
+// flush writes out the rest of the stream.
+func (e *rangeEncoder) flush() {
+	for range 5 {
+		e.shiftLow()
+	}
+}
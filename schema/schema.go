@@ -0,0 +1,417 @@
+// Package schema decodes and encodes packets described at runtime by a JSON
+// schema, so a new client revision can be supported by dropping in a schema
+// file rather than recompiling.
+//
+// A schema lists packets, each an ordered list of fields. The type of a
+// field uses the same syntax as the `jag` struct tags understood by
+// jagbuf.Marshal, a wire type followed by options:
+//
+//	{
+//		"packets": [
+//			{
+//				"name": "walk",
+//				"opcode": 164,
+//				"fields": [
+//					{"name": "x", "type": "u16,le,add"},
+//					{"name": "y", "type": "u16,le"},
+//					{"name": "running", "type": "u8,neg"},
+//					{"name": "steps", "type": "len=u8", "fields": [
+//						{"name": "dx", "type": "i8"},
+//						{"name": "dy", "type": "i8"}
+//					]},
+//					{"name": "colours", "type": "u16", "count": 5}
+//				]
+//			}
+//		]
+//	}
+//
+// A field with nested fields decodes to a map of its own. A type with a len
+// option is a counted list, and a field with a count is a fixed length list.
+// Both decode to []any, except for bytes which decode to []byte.
+//
+// Decoded integers are int64, except for u64 fields which are uint64.
+// Encoding accepts any integer type, as well as float64 values holding a
+// whole number, and bytes accept base64 strings as well as []byte, so maps
+// produced by encoding/json can be encoded directly.
+package schema
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/apogee-rs/jagbuf"
+	"github.com/apogee-rs/jagbuf/internal/tag"
+	"github.com/apogee-rs/jagbuf/internal/wire"
+)
+
+// Schema is a set of packet definitions.
+type Schema struct {
+	Packets []*Packet `json:"packets"`
+
+	byName   map[string]*Packet
+	byOpcode map[int]*Packet
+}
+
+// Packet is the definition of a single packet.
+type Packet struct {
+	Name string `json:"name"`
+	// Opcode is optional, for formats such as cache definitions that are
+	// not sent as packets.
+	Opcode *int    `json:"opcode,omitempty"`
+	Fields []Field `json:"fields"`
+}
+
+// Field is a single field of a packet, or of a nested structure.
+type Field struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	// Count makes the field a fixed length list of Count values.
+	Count int `json:"count,omitempty"`
+	// Fields makes the field a nested structure.
+	Fields []Field `json:"fields,omitempty"`
+
+	spec *tag.Spec
+}
+
+// Parse reads and validates a JSON schema.
+func Parse(r io.Reader) (*Schema, error) {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+
+	s := &Schema{}
+	if err := decoder.Decode(s); err != nil {
+		return nil, fmt.Errorf("schema: %w", err)
+	}
+
+	s.byName = make(map[string]*Packet, len(s.Packets))
+	s.byOpcode = make(map[int]*Packet, len(s.Packets))
+
+	for _, p := range s.Packets {
+		if p.Name == "" {
+			return nil, errors.New("schema: packet without a name")
+		}
+
+		if _, ok := s.byName[p.Name]; ok {
+			return nil, fmt.Errorf("schema: duplicate packet %q", p.Name)
+		}
+		s.byName[p.Name] = p
+
+		if p.Opcode != nil {
+			if other, ok := s.byOpcode[*p.Opcode]; ok {
+				return nil, fmt.Errorf("schema: packets %q and %q share opcode %d", other.Name, p.Name, *p.Opcode)
+			}
+			s.byOpcode[*p.Opcode] = p
+		}
+
+		if err := compileFields(p.Fields); err != nil {
+			return nil, fmt.Errorf("schema: %s.%w", p.Name, err)
+		}
+	}
+
+	return s, nil
+}
+
+// compileFields parses the type of every field, checking it is consistent
+// with the rest of the field definition.
+func compileFields(fields []Field) error {
+	names := make(map[string]bool, len(fields))
+
+	for i := range fields {
+		f := &fields[i]
+
+		if f.Name == "" || names[f.Name] {
+			return fmt.Errorf("%s: missing or duplicate field name", f.Name)
+		}
+		names[f.Name] = true
+
+		spec, err := tag.Parse(f.Type)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+
+		switch {
+		case spec.Kind == tag.Struct && len(f.Fields) == 0:
+			return fmt.Errorf("%s: missing type", f.Name)
+		case spec.Kind != tag.Struct && len(f.Fields) > 0:
+			return fmt.Errorf("%s: only a field without a type can have fields", f.Name)
+		case f.Count < 0 || (f.Count > 0 && spec.Length != nil):
+			return fmt.Errorf("%s: invalid count", f.Name)
+		}
+
+		if spec.Kind == tag.Struct {
+			if err := compileFields(f.Fields); err != nil {
+				return fmt.Errorf("%s.%w", f.Name, err)
+			}
+		}
+
+		f.spec = spec
+	}
+
+	return nil
+}
+
+// Packet returns the packet with the given name.
+func (s *Schema) Packet(name string) (*Packet, bool) {
+	p, ok := s.byName[name]
+	return p, ok
+}
+
+// PacketByOpcode returns the packet with the given opcode.
+func (s *Schema) PacketByOpcode(opcode int) (*Packet, bool) {
+	p, ok := s.byOpcode[opcode]
+	return p, ok
+}
+
+// Decode reads the fields of the packet from b.
+func (p *Packet) Decode(b *jagbuf.Buffer) (map[string]any, error) {
+	values, err := decodeFields(b, p.Fields)
+	if err != nil {
+		return nil, fmt.Errorf("schema: decode %s.%w", p.Name, err)
+	}
+
+	return values, nil
+}
+
+// Encode writes the fields of the packet to b, taking their values from
+// values. Every field must be present.
+func (p *Packet) Encode(b *jagbuf.Buffer, values map[string]any) error {
+	if err := encodeFields(b, p.Fields, values); err != nil {
+		return fmt.Errorf("schema: encode %s.%w", p.Name, err)
+	}
+
+	return nil
+}
+
+func decodeFields(b *jagbuf.Buffer, fields []Field) (map[string]any, error) {
+	values := make(map[string]any, len(fields))
+
+	for i := range fields {
+		f := &fields[i]
+
+		val, err := decodeField(b, f, f.spec, f.Count)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		values[f.Name] = val
+	}
+
+	return values, nil
+}
+
+func decodeField(b *jagbuf.Buffer, f *Field, spec *tag.Spec, count int) (any, error) {
+	if spec.Kind == tag.Bytes {
+		n := b.ReadableBytes()
+		switch {
+		case count > 0:
+			n = count
+		case spec.Length != nil:
+			var err error
+			if n, err = wire.ReadCount[jagbuf.Order, jagbuf.Transform](b, spec.Length); err != nil {
+				return nil, err
+			}
+		}
+
+		data := make([]byte, n)
+		return data, b.ReadBytes(data)
+	}
+
+	if count > 0 || spec.Length != nil {
+		if spec.Length != nil {
+			var err error
+			if count, err = wire.ReadCount[jagbuf.Order, jagbuf.Transform](b, spec.Length); err != nil {
+				return nil, err
+			}
+		}
+
+		elem := spec.Element()
+		list := make([]any, count)
+		for i := range list {
+			val, err := decodeField(b, f, elem, 0)
+			if err != nil {
+				return nil, fmt.Errorf("index %d: %w", i, err)
+			}
+			list[i] = val
+		}
+		return list, nil
+	}
+
+	switch spec.Kind {
+	case tag.Int, tag.Smart:
+		bits, err := wire.ReadInt[jagbuf.Order, jagbuf.Transform](b, spec)
+		if err != nil {
+			return nil, err
+		}
+		if spec.Kind == tag.Int && spec.Width == 8 && !spec.Signed {
+			return bits, nil
+		}
+		return int64(bits), nil
+	case tag.String:
+		var str string
+		var err error
+		if spec.Jag {
+			str, err = b.ReadJagString()
+		} else {
+			str, err = b.ReadString()
+		}
+		if spec.CP1252 {
+			str = jagbuf.DecodeCP1252([]byte(str))
+		}
+		return str, err
+	default:
+		return decodeFields(b, f.Fields)
+	}
+}
+
+func encodeFields(b *jagbuf.Buffer, fields []Field, values map[string]any) error {
+	for i := range fields {
+		f := &fields[i]
+
+		val, ok := values[f.Name]
+		if !ok {
+			return fmt.Errorf("%s: missing value", f.Name)
+		}
+
+		if err := encodeField(b, f, f.spec, f.Count, val); err != nil {
+			return fmt.Errorf("%s: %w", f.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func encodeField(b *jagbuf.Buffer, f *Field, spec *tag.Spec, count int, val any) error {
+	if spec.Kind == tag.Bytes {
+		var data []byte
+		switch val := val.(type) {
+		case []byte:
+			data = val
+		case string:
+			// encoding/json encodes []byte as a base64 string.
+			var err error
+			if data, err = base64.StdEncoding.DecodeString(val); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("expected []byte, got %T", val)
+		}
+
+		switch {
+		case count > 0 && len(data) != count:
+			return fmt.Errorf("expected %d bytes, got %d", count, len(data))
+		case spec.Length != nil:
+			if err := wire.WriteCount[jagbuf.Order, jagbuf.Transform](b, spec.Length, len(data)); err != nil {
+				return err
+			}
+		}
+
+		b.Write(data)
+		return nil
+	}
+
+	if count > 0 || spec.Length != nil {
+		list, ok := val.([]any)
+		if !ok {
+			return fmt.Errorf("expected []any, got %T", val)
+		}
+
+		if count > 0 && len(list) != count {
+			return fmt.Errorf("expected %d values, got %d", count, len(list))
+		}
+
+		if spec.Length != nil {
+			if err := wire.WriteCount[jagbuf.Order, jagbuf.Transform](b, spec.Length, len(list)); err != nil {
+				return err
+			}
+		}
+
+		elem := spec.Element()
+		for i, v := range list {
+			if err := encodeField(b, f, elem, 0, v); err != nil {
+				return fmt.Errorf("index %d: %w", i, err)
+			}
+		}
+		return nil
+	}
+
+	switch spec.Kind {
+	case tag.Int, tag.Smart:
+		bits, err := intBits(spec, val)
+		if err != nil {
+			return err
+		}
+		wire.WriteInt[jagbuf.Order, jagbuf.Transform](b, spec, bits)
+	case tag.String:
+		str, ok := val.(string)
+		if !ok {
+			return fmt.Errorf("expected string, got %T", val)
+		}
+		if spec.Jag {
+			b.WriteUint8(0)
+		}
+		if spec.CP1252 {
+			b.Write(jagbuf.EncodeCP1252(str))
+			b.WriteUint8(0)
+		} else {
+			b.WriteString(str)
+		}
+	default:
+		values, ok := val.(map[string]any)
+		if !ok {
+			return fmt.Errorf("expected map[string]any, got %T", val)
+		}
+		return encodeFields(b, f.Fields, values)
+	}
+
+	return nil
+}
+
+// intBits converts any integer value, or a float64 holding a whole number,
+// to the bits to write for spec, checking it is in range.
+func intBits(spec *tag.Spec, val any) (uint64, error) {
+	var i int64
+	var u uint64
+	signed := true
+
+	switch v := val.(type) {
+	case int:
+		i = int64(v)
+	case int8:
+		i = int64(v)
+	case int16:
+		i = int64(v)
+	case int32:
+		i = int64(v)
+	case int64:
+		i = v
+	case uint:
+		u, signed = uint64(v), false
+	case uint8:
+		u, signed = uint64(v), false
+	case uint16:
+		u, signed = uint64(v), false
+	case uint32:
+		u, signed = uint64(v), false
+	case uint64:
+		u, signed = v, false
+	case float64:
+		switch {
+		case v != math.Trunc(v) || v < math.MinInt64 || v >= math.MaxUint64:
+			return 0, fmt.Errorf("value %v is not an integer", v)
+		case v < 0:
+			i = int64(v)
+		default:
+			u, signed = uint64(v), false
+		}
+	default:
+		return 0, fmt.Errorf("expected an integer, got %T", val)
+	}
+
+	if signed {
+		return wire.IntBits(spec, i)
+	}
+	return wire.UintBits(spec, u)
+}
@@ -0,0 +1,313 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/apogee-rs/jagbuf"
+)
+
+const testSchema = `{
+	"packets": [
+		{
+			"name": "walk",
+			"opcode": 164,
+			"fields": [
+				{"name": "x", "type": "u16,le,add"},
+				{"name": "y", "type": "u16,le"},
+				{"name": "running", "type": "u8,neg"},
+				{"name": "steps", "type": "len=u8", "fields": [
+					{"name": "dx", "type": "i8"},
+					{"name": "dy", "type": "i8"}
+				]},
+				{"name": "colours", "type": "u16", "count": 2}
+			]
+		},
+		{
+			"name": "chat",
+			"opcode": 4,
+			"fields": [
+				{"name": "effects", "type": "smart"},
+				{"name": "offset", "type": "ssmart"},
+				{"name": "message", "type": "jagstring,cp1252"},
+				{"name": "id", "type": "u64"},
+				{"name": "payload", "type": "bytes,len=u16"},
+				{"name": "trailer", "type": "bytes"}
+			]
+		},
+		{
+			"name": "obj",
+			"fields": [
+				{"name": "ids", "type": "i32,v2,len=smart"}
+			]
+		}
+	]
+}`
+
+func parseTestSchema(t *testing.T) *Schema {
+	t.Helper()
+
+	s, err := Parse(strings.NewReader(testSchema))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestPacket_Decode(t *testing.T) {
+	s := parseTestSchema(t)
+
+	walk, ok := s.PacketByOpcode(164)
+	if !ok || walk.Name != "walk" {
+		t.Fatalf("PacketByOpcode(164) = %v, %t", walk, ok)
+	}
+
+	b := jagbuf.NewBuffer()
+	b.WriteUintN(2, 3200, jagbuf.LittleEndian, jagbuf.TransformAdd)
+	b.WriteUint16LE(3200)
+	b.WriteUintN(1, 1, jagbuf.BigEndian, jagbuf.TransformNeg)
+	b.WriteUint8(2)
+	b.WriteInt8(-1)
+	b.WriteInt8(1)
+	b.WriteInt8(0)
+	b.WriteInt8(-1)
+	b.WriteUint16(10)
+	b.WriteUint16(20)
+
+	got, err := walk.Decode(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"x":       int64(3200),
+		"y":       int64(3200),
+		"running": int64(1),
+		"steps": []any{
+			map[string]any{"dx": int64(-1), "dy": int64(1)},
+			map[string]any{"dx": int64(0), "dy": int64(-1)},
+		},
+		"colours": []any{int64(10), int64(20)},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Decode() = %v, want %v", got, want)
+	}
+}
+
+func TestPacket_RoundTrip(t *testing.T) {
+	s := parseTestSchema(t)
+
+	tests := []struct {
+		packet string
+		values map[string]any
+	}{
+		{"walk", map[string]any{
+			"x":       int64(3222),
+			"y":       int64(3218),
+			"running": int64(0),
+			"steps":   []any{map[string]any{"dx": int64(-128), "dy": int64(127)}},
+			"colours": []any{int64(0), int64(0xFFFF)},
+		}},
+		{"chat", map[string]any{
+			"effects": int64(0x7FFF),
+			"offset":  int64(-0x4000),
+			"message": "Héllo – wörld",
+			"id":      uint64(1 << 63),
+			"payload": []byte{1, 2, 3},
+			"trailer": []byte{4, 5},
+		}},
+		{"obj", map[string]any{
+			"ids": []any{int64(-1), int64(1 << 30)},
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.packet, func(t *testing.T) {
+			p, ok := s.Packet(test.packet)
+			if !ok {
+				t.Fatalf("Packet(%q) not found", test.packet)
+			}
+
+			b := jagbuf.NewBuffer()
+			if err := p.Encode(b, test.values); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := p.Decode(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.values) {
+				t.Errorf("Decode() = %v, want %v", got, test.values)
+			}
+			if b.ReadableBytes() != 0 {
+				t.Errorf("%d bytes left after Decode()", b.ReadableBytes())
+			}
+		})
+	}
+}
+
+func TestPacket_EncodeConversions(t *testing.T) {
+	s := parseTestSchema(t)
+	walk, _ := s.Packet("walk")
+
+	values := map[string]any{
+		"x":       float64(3200),
+		"y":       uint16(3200),
+		"running": 1,
+		"steps":   []any{},
+		"colours": []any{int8(1), uint64(2)},
+	}
+
+	b := jagbuf.NewBuffer()
+	if err := walk.Encode(b, values); err != nil {
+		t.Fatal(err)
+	}
+
+	want := jagbuf.NewBuffer()
+	want.WriteUintN(2, 3200, jagbuf.LittleEndian, jagbuf.TransformAdd)
+	want.WriteUint16LE(3200)
+	want.WriteUintN(1, 1, jagbuf.BigEndian, jagbuf.TransformNeg)
+	want.WriteUint8(0)
+	want.WriteUint16(1)
+	want.WriteUint16(2)
+
+	if !bytes.Equal(b.Bytes(), want.Bytes()) {
+		t.Errorf("Encode() = %v, want %v", b.Bytes(), want.Bytes())
+	}
+}
+
+func TestPacket_EncodeJSON(t *testing.T) {
+	s := parseTestSchema(t)
+	chat, _ := s.Packet("chat")
+
+	b := jagbuf.NewBuffer()
+	err := chat.Encode(b, map[string]any{
+		"effects": 1,
+		"offset":  -1,
+		"message": "hi",
+		"id":      2,
+		"payload": []byte{1, 2, 3},
+		"trailer": []byte{4, 5},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := b.Bytes()
+
+	decoded, err := chat.Decode(jagbuf.Wrap(want))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		t.Fatal(err)
+	}
+
+	b = jagbuf.NewBuffer()
+	if err := chat.Encode(b, values); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b.Bytes(), want) {
+		t.Errorf("Encode() = %v, want %v", b.Bytes(), want)
+	}
+
+	values["payload"] = "not base64"
+	if err := chat.Encode(jagbuf.NewBuffer(), values); err == nil {
+		t.Error("Encode() of invalid base64 did not fail")
+	}
+}
+
+func TestPacket_EncodeErrors(t *testing.T) {
+	s := parseTestSchema(t)
+	walk, _ := s.Packet("walk")
+
+	valid := func() map[string]any {
+		return map[string]any{
+			"x":       0,
+			"y":       0,
+			"running": 0,
+			"steps":   []any{},
+			"colours": []any{0, 0},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		field  string
+		value  any
+		delete bool
+	}{
+		{name: "missing", field: "x", delete: true},
+		{name: "out of range", field: "x", value: 0x10000},
+		{name: "negative", field: "y", value: -1},
+		{name: "fraction", field: "x", value: 1.5},
+		{name: "wrong type", field: "x", value: "1"},
+		{name: "wrong count", field: "colours", value: []any{0}},
+		{name: "not a list", field: "steps", value: 0},
+		{name: "bad element", field: "steps", value: []any{map[string]any{"dx": 0}}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			values := valid()
+			if test.delete {
+				delete(values, test.field)
+			} else {
+				values[test.field] = test.value
+			}
+
+			if err := walk.Encode(jagbuf.NewBuffer(), values); err == nil {
+				t.Error("Encode() did not fail")
+			}
+		})
+	}
+}
+
+func TestPacket_DecodeTruncated(t *testing.T) {
+	s := parseTestSchema(t)
+	walk, _ := s.Packet("walk")
+
+	b := jagbuf.NewBuffer()
+	b.WriteUint16(0)
+	b.WriteUint16(0)
+	b.WriteUint8(0)
+	b.WriteUint8(200)
+
+	if _, err := walk.Decode(b); !errors.Is(err, io.EOF) {
+		t.Errorf("Decode() error = %v, want io.EOF", err)
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := map[string]string{
+		"syntax":          `{"packets": [`,
+		"unknown key":     `{"packets": [{"name": "a", "size": 1}]}`,
+		"no name":         `{"packets": [{"fields": []}]}`,
+		"duplicate name":  `{"packets": [{"name": "a"}, {"name": "a"}]}`,
+		"shared opcode":   `{"packets": [{"name": "a", "opcode": 1}, {"name": "b", "opcode": 1}]}`,
+		"bad type":        `{"packets": [{"name": "a", "fields": [{"name": "x", "type": "u12"}]}]}`,
+		"missing type":    `{"packets": [{"name": "a", "fields": [{"name": "x"}]}]}`,
+		"duplicate field": `{"packets": [{"name": "a", "fields": [{"name": "x", "type": "u8"}, {"name": "x", "type": "u8"}]}]}`,
+		"typed struct":    `{"packets": [{"name": "a", "fields": [{"name": "x", "type": "u8", "fields": [{"name": "y", "type": "u8"}]}]}]}`,
+		"count and len":   `{"packets": [{"name": "a", "fields": [{"name": "x", "type": "u8,len=u8", "count": 2}]}]}`,
+		"nested":          `{"packets": [{"name": "a", "fields": [{"name": "x", "fields": [{"name": "y", "type": "le"}]}]}]}`,
+	}
+
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse(strings.NewReader(src)); err == nil {
+				t.Error("Parse() did not fail")
+			}
+		})
+	}
+}